@@ -1,11 +1,12 @@
 package benchmarks
 
 import (
-	"bitbucket.org/calendarbite/jsonv"
 	"bytes"
 	"encoding/json"
 	"reflect"
 	"testing"
+
+	"github.com/Satook/jsonv"
 )
 
 type BasicStruct struct {
@@ -0,0 +1,66 @@
+package jsonv
+
+import (
+	"io"
+)
+
+/*
+Reads and validates a stream of JSON values separated by whitespace, e.g. JSON
+Lines (NDJSON), or values simply written one after another. As with
+encoding/json's Decoder:
+
+	d := NewDecoder(r, parser)
+	for d.More() {
+		var rec Record
+		if err := d.Decode(&rec); err != nil {
+			...
+		}
+	}
+
+The same Scanner, and so its buffer, is used for the whole stream, configured
+with the parser's options, so each value is read starting from where the last
+one ended.
+*/
+type Decoder struct {
+	s *Scanner
+	p *ValidatingParser
+}
+
+func NewDecoder(r io.Reader, p *ValidatingParser) *Decoder {
+	return &Decoder{s: p.configure(NewScanner(r)), p: p}
+}
+
+/*
+Reports whether there's another value in the stream. It's true if reading ahead
+failed for any reason other than the stream ending, so that Decode can return
+the error.
+*/
+func (d *Decoder) More() bool {
+	tok, err := d.s.PeekToken()
+	return tok != TokenError || err != io.EOF
+}
+
+/*
+Reads the next value from the stream and parses it into v.
+
+Returns io.EOF when there's nothing but whitespace left in the stream. As with
+ValidatingParser.Parse, a value that fails validation is returned as a
+ValidationError, after which the next value can still be read. A *ParseError
+means the stream is malformed, and it can't be read any further.
+*/
+func (d *Decoder) Decode(v interface{}) error {
+	d.p.checkDest(v)
+
+	if tok, err := d.s.PeekToken(); tok == TokenError {
+		return err
+	}
+	return parseRoot(d.p.schema, d.s, v)
+}
+
+/*
+Returns the number of bytes of the stream read by Decode so far, see
+Scanner.BytesRead.
+*/
+func (d *Decoder) BytesRead() int {
+	return d.s.BytesRead()
+}
@@ -0,0 +1,99 @@
+package jsonv
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func Test_Decoder(t *testing.T) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String()), Prop("Fullname", String(MinLen(2)))))
+
+	json := `{"Captcha": "Zing", "Fullname": "Bob"}
+{"Captcha": "Zong", "Fullname": "J"}
+
+  {"Captcha": "Zang", "Fullname": "Al"}	{"Captcha": "Zeng", "Fullname": "Jo"}{"Captcha": "Zung", "Fullname": "Ed"}
+`
+	d := NewDecoder(bytes.NewBufferString(json), parser)
+
+	wants := []simpleStruct{{"Zing", "Bob"}, {}, {"Zang", "Al"}, {"Zeng", "Jo"}, {"Zung", "Ed"}}
+	for i, want := range wants {
+		var got simpleStruct
+		err := d.Decode(&got)
+		if i == 1 {
+			// invalid, but we can carry on
+			if _, ok := err.(ValidationError); !ok {
+				t.Fatalf("Value %d: Got %v, want a ValidationError", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Value %d: %v", i, err)
+		} else if got != want {
+			t.Fatalf("Value %d: Got %v, want %v", i, got, want)
+		}
+	}
+
+	var got simpleStruct
+	if err := d.Decode(&got); err != io.EOF {
+		t.Fatalf("Got %v, want EOF", err)
+	} else if d.BytesRead() != len(json) {
+		t.Fatalf("Got %d bytes read, want %d", d.BytesRead(), len(json))
+	}
+}
+
+func Test_DecoderMore(t *testing.T) {
+	parser := Parser(new(int64), Integer(MinI(0)))
+	d := NewDecoder(bytes.NewBufferString("1 2\n-3\n4\n\n"), parser)
+
+	var got []int64
+	var invalid int
+	for d.More() {
+		var v int64
+		if err := d.Decode(&v); err == nil {
+			got = append(got, v)
+		} else if _, ok := err.(ValidationError); ok {
+			invalid++
+		} else {
+			t.Fatal(err)
+		}
+	}
+	if want := []int64{1, 2, 4}; !reflect.DeepEqual(got, want) || invalid != 1 {
+		t.Fatalf("Got %v and %d invalid, want %v and 1", got, invalid, want)
+	}
+
+	// a malformed value is still handed to Decode
+	d = NewDecoder(bytes.NewBufferString("1 @"), parser)
+	var v int64
+	if !d.More() || d.Decode(&v) != nil || !d.More() {
+		t.Fatal("Got no more values, want two")
+	} else if _, ok := d.Decode(&v).(*ParseError); !ok {
+		t.Fatal("Got no ParseError for the malformed value")
+	}
+}
+
+func Test_DecoderMalformed(t *testing.T) {
+	parser := Parser(new(int64), Integer())
+	d := NewDecoder(bytes.NewBufferString("1\n2\n@\n4"), parser)
+
+	var got int64
+	for _, want := range []int64{1, 2} {
+		if err := d.Decode(&got); err != nil {
+			t.Fatal(err)
+		} else if got != want {
+			t.Fatalf("Got %v, want %v", got, want)
+		}
+	}
+	if err := d.Decode(&got); err == nil {
+		t.Fatal("Got no error, wanted one")
+	} else if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got %v, want a ParseError", err)
+	}
+
+	// an empty stream has no values
+	d = NewDecoder(bytes.NewBufferString(" \n "), parser)
+	if err := d.Decode(&got); err != io.EOF {
+		t.Fatalf("Got %v, want EOF", err)
+	}
+}
@@ -0,0 +1,160 @@
+package jsonv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/*
+Wraps the scanner's reader so that input starting with a UTF-16 or UTF-32 byte
+order mark (BOM) is transcoded to UTF-8 before being tokenized, and a leading
+UTF-8 BOM is dropped. Input without a BOM is read as UTF-8, as normal.
+
+RFC 8259 requires JSON to be UTF-8, so this is off by default and is intended
+for tolerant ingestion of data from clients that don't comply, e.g. some
+Windows/.NET software.
+
+Must be called before anything is read from the scanner. Has no effect on a
+Scanner created with NewBytesScanner.
+*/
+func (s *Scanner) WithEncodingDetection() *Scanner {
+	if !s.fixed {
+		s.r = &encodingDetector{r: s.r}
+	}
+	return s
+}
+
+/*
+Sniffs the BOM on the first Read and from then on reads via the appropriate
+transcoder.
+*/
+type encodingDetector struct {
+	r        io.Reader
+	detected bool
+}
+
+func (d *encodingDetector) Read(p []byte) (int, error) {
+	if !d.detected {
+		d.detected = true
+
+		var bom [4]byte
+		n, err := io.ReadFull(d.r, bom[:])
+		if err == io.ErrUnexpectedEOF {
+			err = nil
+		} else if err != nil {
+			return 0, err
+		}
+		d.r = detectEncoding(bom[:n], d.r)
+	}
+
+	return d.r.Read(p)
+}
+
+/*
+Picks the reader for the input, given its first few bytes, prefix, and the rest
+of the input, r.
+*/
+func detectEncoding(prefix []byte, r io.Reader) io.Reader {
+	has := func(bom ...byte) bool {
+		return bytes.HasPrefix(prefix, bom)
+	}
+
+	// check UTF-32 first as the UTF-32LE BOM starts with the UTF-16LE one
+	switch {
+	case has(0x00, 0x00, 0xFE, 0xFF):
+		return &utfReader{r: r, width: 4, order: binary.BigEndian, in: prefix[4:]}
+	case has(0xFF, 0xFE, 0x00, 0x00):
+		return &utfReader{r: r, width: 4, order: binary.LittleEndian, in: prefix[4:]}
+	case has(0xFE, 0xFF):
+		return &utfReader{r: r, width: 2, order: binary.BigEndian, in: prefix[2:]}
+	case has(0xFF, 0xFE):
+		return &utfReader{r: r, width: 2, order: binary.LittleEndian, in: prefix[2:]}
+	case has(0xEF, 0xBB, 0xBF):
+		prefix = prefix[3:]
+	}
+
+	return io.MultiReader(bytes.NewReader(prefix), r)
+}
+
+/*
+Transcodes UTF-16 (width 2) or UTF-32 (width 4) input into UTF-8.
+
+Invalid code points and unpaired surrogates are replaced with
+unicode.ReplacementChar.
+*/
+type utfReader struct {
+	r     io.Reader
+	width int
+	order binary.ByteOrder
+	in    []byte // input not yet decoded
+	out   []byte // decoded output not yet read
+	err   error
+}
+
+func (u *utfReader) Read(p []byte) (int, error) {
+	for len(u.out) == 0 {
+		if u.err != nil {
+			if len(u.in) > 0 {
+				// a partial code unit at the end of the input
+				u.in = u.in[:0]
+				u.appendRune(unicode.ReplacementChar)
+				continue
+			}
+			return 0, u.err
+		}
+
+		var buf [READ_LEN]byte
+		var n int
+		n, u.err = u.r.Read(buf[:])
+		u.in = append(u.in, buf[:n]...)
+		u.decode()
+	}
+
+	n := copy(p, u.out)
+	u.out = u.out[n:]
+	return n, nil
+}
+
+/*
+Decodes as many whole characters as we can from u.in into u.out.
+*/
+func (u *utfReader) decode() {
+	i := 0
+	for ; i+u.width <= len(u.in); i += u.width {
+		var r rune
+		if u.width == 4 {
+			r = rune(u.order.Uint32(u.in[i:]))
+		} else {
+			r = rune(u.order.Uint16(u.in[i:]))
+			if utf16.IsSurrogate(r) {
+				if i+4 > len(u.in) {
+					if u.err == nil {
+						// wait for the other half of the pair
+						break
+					}
+				} else if dec := utf16.DecodeRune(r, rune(u.order.Uint16(u.in[i+2:]))); dec != unicode.ReplacementChar {
+					r = dec
+					i += 2
+				}
+			}
+		}
+
+		if !utf8.ValidRune(r) {
+			r = unicode.ReplacementChar
+		}
+		u.appendRune(r)
+	}
+
+	// keep any partial character for next time
+	u.in = u.in[:copy(u.in, u.in[i:])]
+}
+
+func (u *utfReader) appendRune(r rune) {
+	var b [utf8.UTFMax]byte
+	n := utf8.EncodeRune(b[:], r)
+	u.out = append(u.out, b[:n]...)
+}
@@ -0,0 +1,82 @@
+package jsonv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, order binary.ByteOrder) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		order.PutUint16(b[2*i:], u)
+	}
+	return b
+}
+
+func encodeUTF32(s string, order binary.ByteOrder) []byte {
+	var b []byte
+	for _, r := range s {
+		var u [4]byte
+		order.PutUint32(u[:], uint32(r))
+		b = append(b, u[:]...)
+	}
+	return b
+}
+
+func Test_ScannerEncodingDetection(t *testing.T) {
+	json := `{"Captcha": "Zing ⌘ 😀", "Fullname": "Bob"}`
+	want := simpleStruct{"Zing ⌘ 😀", "Bob"}
+
+	cases := [][]byte{
+		[]byte(json),
+		append([]byte{0xEF, 0xBB, 0xBF}, json...),
+		append([]byte{0xFF, 0xFE}, encodeUTF16(json, binary.LittleEndian)...),
+		append([]byte{0xFE, 0xFF}, encodeUTF16(json, binary.BigEndian)...),
+		append([]byte{0xFF, 0xFE, 0x00, 0x00}, encodeUTF32(json, binary.LittleEndian)...),
+		append([]byte{0x00, 0x00, 0xFE, 0xFF}, encodeUTF32(json, binary.BigEndian)...),
+	}
+
+	schema := Struct(Prop("Captcha", String()), Prop("Fullname", String()))
+	if err := schema.Prepare(reflect.TypeOf(want)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, c := range cases {
+		// use a 1 byte at a time reader to split characters across reads
+		s := NewScanner(&oneByteReader{bytes.NewReader(c)}).WithEncodingDetection()
+
+		var got simpleStruct
+		if err := schema.Parse(func() string { return "/" }, s, &got); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		} else if got != want {
+			t.Errorf("Case %d: Got %v, want %v", i, got, want)
+		}
+	}
+
+	// short input, without a BOM
+	s := NewScanner(bytes.NewBufferString("1")).WithEncodingDetection()
+	if tok, buf, err := s.ReadToken(); tok != TokenNumber || string(buf) != "1" {
+		t.Errorf("Got %v %s %v, want number 1", tok, buf, err)
+	}
+
+	// without detection, UTF-16 is invalid
+	s = NewScanner(bytes.NewReader(cases[2]))
+	if err := schema.Parse(func() string { return "/" }, s, &simpleStruct{}); err == nil {
+		t.Errorf("Got no error for UTF-16 without detection")
+	}
+}
+
+type oneByteReader struct {
+	r *bytes.Reader
+}
+
+func (o *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return o.r.Read(p[:1])
+}
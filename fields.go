@@ -112,8 +112,8 @@ func typeFields(t reflect.Type) []field {
 				index[len(f.index)] = i
 
 				ft := sf.Type
-				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
-					// Follow pointer.
+				for ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					// Follow pointers, however many there are.
 					ft = ft.Elem()
 				}
 
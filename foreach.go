@@ -0,0 +1,113 @@
+package jsonv
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+/*
+Reads a JSON object from r, one property at a time, parsing each value into v
+with valueSchema and passing it, along with its key, to fn. This allows objects
+that are too large to hold in memory, e.g. a dictionary with millions of
+entries, to be processed without ever building a map.
+
+v must be a non-nil pointer and is re-used for every value, it's reset to its
+zero value before each is parsed, so fn must copy anything it wants to keep.
+
+Values that fail validation aren't passed to fn, their errors are collected and
+returned as a ValidationError once the whole object has been read. As with
+ValidatingParser.Parse, malformed input stops parsing with a *ParseError.
+Parsing also stops at the first error returned by fn, which is returned as is.
+*/
+func ForEachProp(r io.Reader, valueSchema SchemaType, v interface{}, fn func(key string, v interface{}) error) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		panic(fmt.Errorf("Expected a non-nil Ptr, got \"%v\"", rv.Type()))
+	}
+	if err := prepareSchema(rv.Elem().Type(), valueSchema); err != nil {
+		return err
+	}
+
+	s := NewScanner(r)
+	var fnErr error
+	err := forEachProp(s, valueSchema, v, func(key string, v interface{}) error {
+		fnErr = fn(key, v)
+		return fnErr
+	})
+	if err != nil && err == fnErr {
+		return err
+	}
+	return rootError(s, err)
+}
+
+func forEachProp(s *Scanner, schema SchemaType, v interface{}, fn func(key string, v interface{}) error) error {
+	dest := reflect.ValueOf(v).Elem()
+	zero := reflect.Zero(dest.Type())
+
+	// read the '{'
+	tok, _, err := s.ReadToken()
+	if tok == TokenError {
+		return err
+	} else if tok != TokenObjectBegin {
+		return NewParseError("Expected '{' not %v", tok)
+	}
+
+	var errs ValidationError
+	var key string
+	keyPath := func() string {
+		return "/" + key
+	}
+
+	for first := true; ; first = false {
+		// read the key, or '}'
+		tok, keyb, err := s.ReadKey()
+		if tok == TokenError {
+			return err
+		} else if tok == TokenObjectEnd {
+			if !first && !s.allowTrailingComma {
+				return NewParseError(ERROR_TRAILING_COMMA_OBJ)
+			}
+			break
+		} else if tok != TokenString {
+			return NewParseError("Expected object property name or '}' not %v", tok)
+		}
+		key, _ = Unquote(keyb)
+
+		// read the ':'
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return err
+		} else if tok != TokenPropSep {
+			return NewParseError("Expected ':' not %v", tok)
+		}
+
+		dest.Set(zero)
+		err = schema.Parse(keyPath, s, v)
+		if err == ErrAbsent {
+			err = NewSingleVErr(keyPath(), ERROR_PROP_REQUIRED)
+		}
+		if verr, ok := err.(ValidationError); ok {
+			if errs, err = s.addErrors(errs, verr); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else if err := fn(key, v); err != nil {
+			return err
+		}
+
+		// we want a , or a }
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return err
+		} else if tok == TokenObjectEnd {
+			break
+		} else if tok != TokenItemSep {
+			return NewParseError("Expected ',' or '}' not %v", tok)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package jsonv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_ForEachProp(t *testing.T) {
+	const count = 100000
+
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		fmt.Fprintf(&buf, `"key%d": {"Captcha": "c%d", "Fullname": "n%d"}`, i, i, i)
+	}
+	buf.WriteString("}")
+
+	schema := Struct(Prop("Captcha", String()), Prop("Fullname", String()))
+	seen := 0
+	err := ForEachProp(&buf, schema, &simpleStruct{}, func(key string, v interface{}) error {
+		got := *v.(*simpleStruct)
+		want := simpleStruct{fmt.Sprintf("c%d", seen), fmt.Sprintf("n%d", seen)}
+		if wantKey := fmt.Sprintf("key%d", seen); key != wantKey {
+			return fmt.Errorf("Got key %v, want %v", key, wantKey)
+		} else if got != want {
+			return fmt.Errorf("Got %v for %v, want %v", got, key, want)
+		}
+		seen++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if seen != count {
+		t.Fatalf("Got %d props, want %d", seen, count)
+	}
+}
+
+func Test_ForEachPropErrors(t *testing.T) {
+	// invalid values are skipped and reported at the end
+	var keys []string
+	err := ForEachProp(strings.NewReader(`{"a": 1, "b": -1, "c": 3, "d": -2}`), Integer(MinI(0)), new(int64), func(key string, v interface{}) error {
+		keys = append(keys, fmt.Sprintf("%s=%d", key, *v.(*int64)))
+		return nil
+	})
+	if verr, ok := err.(ValidationError); !ok {
+		t.Fatalf("Got %v, want a ValidationError", err)
+	} else if len(verr) != 2 || verr[0].Path != "/b" || verr[1].Path != "/d" {
+		t.Fatalf("Got errors %v, want errors for /b and /d", verr)
+	} else if got := strings.Join(keys, ","); got != "a=1,c=3" {
+		t.Fatalf("Got %v, want a=1,c=3", got)
+	}
+
+	// the first error from the callback stops parsing
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err = ForEachProp(strings.NewReader(`{"a": 1, "b": 2, "c": 3}`), Integer(), new(int64), func(key string, v interface{}) error {
+		calls++
+		if key == "b" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("Got %v, want %v", err, stop)
+	} else if calls != 2 {
+		t.Fatalf("Got %d calls, want 2", calls)
+	}
+
+	// malformed input
+	err = ForEachProp(strings.NewReader(`[1, 2]`), Integer(), new(int64), func(string, interface{}) error { return nil })
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got %v, want a ParseError", err)
+	}
+}
@@ -0,0 +1,63 @@
+package jsonv
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+)
+
+/*
+Reads and validates a stream of JSON values that are each prefixed with their
+length, as a 4-byte big-endian unsigned integer.
+
+Each frame must contain exactly one JSON value, optionally surrounded by
+whitespace.
+*/
+type FramedDecoder struct {
+	r      io.Reader
+	p      *ValidatingParser
+	lenBuf [4]byte
+}
+
+func NewFramedDecoder(r io.Reader, p *ValidatingParser) *FramedDecoder {
+	return &FramedDecoder{r: r, p: p}
+}
+
+/*
+Reads the next frame from the stream and parses it into v.
+
+Returns io.EOF when the stream ends cleanly between frames, and
+io.ErrUnexpectedEOF if it ends part way through a frame. As with
+ValidatingParser.Parse, any problems with the frame's contents are returned as a
+ValidationError or *ParseError, after which the next frame can still be read.
+A frame holding anything other than a single value is a *ParseError, giving the
+offset within the frame of the first byte after the value that isn't
+whitespace.
+*/
+func (d *FramedDecoder) Decode(v interface{}) error {
+	d.p.checkDest(v)
+
+	if _, err := io.ReadFull(d.r, d.lenBuf[:]); err != nil {
+		return err
+	}
+	frame := &io.LimitedReader{R: d.r, N: int64(binary.BigEndian.Uint32(d.lenBuf[:]))}
+
+	s := d.p.configure(NewScanner(frame))
+	err := d.p.parse(s, v)
+	if _, ok := err.(ValidationError); err == nil || ok {
+		// make sure the value was the only thing in the frame, peeking leaves
+		// rcount at the first byte of whatever else there is
+		if tok, perr := s.PeekToken(); tok != TokenError || perr != io.EOF {
+			err = NewParseError(ERROR_FRAME_TRAILING_DATA, s.rcount)
+		}
+	}
+
+	// skip whatever is left so we're ready for the next frame
+	if _, cerr := io.Copy(ioutil.Discard, frame); cerr != nil {
+		return cerr
+	} else if frame.N > 0 {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
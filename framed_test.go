@@ -0,0 +1,68 @@
+package jsonv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func writeFrame(buf *bytes.Buffer, json string) {
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(json)))
+	buf.Write(l[:])
+	buf.WriteString(json)
+}
+
+func Test_FramedDecoder(t *testing.T) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String()), Prop("Fullname", String())))
+
+	var buf bytes.Buffer
+	writeFrame(&buf, `{"Captcha": "Zing", "Fullname": "Bob"}`)
+	writeFrame(&buf, ` {"Captcha": "Zong", "Fullname": "Jim"} `)
+	writeFrame(&buf, `{"Captcha": "Zang", "Fullname": "Al"} {}`)
+	writeFrame(&buf, `{"Captcha": "Zeng", "Fullname": "Jo"}`)
+	d := NewFramedDecoder(&buf, parser)
+
+	for i, want := range []simpleStruct{{"Zing", "Bob"}, {"Zong", "Jim"}} {
+		var got simpleStruct
+		if err := d.Decode(&got); err != nil {
+			t.Fatalf("Frame %d: %v", i, err)
+		} else if got != want {
+			t.Fatalf("Frame %d: Got %v, want %v", i, got, want)
+		}
+	}
+
+	// trailing data is an error, but we can carry on
+	var got simpleStruct
+	if err := d.Decode(&got); err == nil {
+		t.Fatalf("Got no error for frame with trailing data")
+	} else if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got %v, want a ParseError", err)
+	} else if want := "Expected exactly one JSON value in frame, found more at byte 38"; err.Error() != want {
+		t.Fatalf("Got \"%v\", want \"%v\"", err, want)
+	}
+
+	if err := d.Decode(&got); err != nil {
+		t.Fatal(err)
+	} else if want := (simpleStruct{"Zeng", "Jo"}); got != want {
+		t.Fatalf("Got %v, want %v", got, want)
+	}
+
+	if err := d.Decode(&got); err != io.EOF {
+		t.Fatalf("Got %v, want EOF", err)
+	}
+}
+
+func Test_FramedDecoderTruncated(t *testing.T) {
+	parser := Parser(new(int64), Integer())
+
+	var buf bytes.Buffer
+	writeFrame(&buf, `12345`)
+	buf.Truncate(buf.Len() - 2)
+
+	var got int64
+	if err := NewFramedDecoder(&buf, parser).Decode(&got); err != io.ErrUnexpectedEOF {
+		t.Fatalf("Got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
@@ -1,9 +1,12 @@
 package jsonv
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"reflect"
+	"sync"
+	"time"
 )
 
 /*
@@ -14,10 +17,44 @@ whereas a malformed number, e.g. "e22", would require parsing to stop.
 
 E.g. 2: If the root cannot be parsed, the path will be "/" and the Error string
 a, hopefully, useful message for the client.
+
+As there's an unexported field, literals must name their fields, e.g.
+InvalidData{Path: "/Name", Error: "Required"}. Values compare equal with == if
+their Path and Error do, unless one keeps the error it came from, see Err, which
+is only the case for those added with ValidationError.AddErr.
 */
 type InvalidData struct {
 	Path  string
 	Error string
+
+	err *keptError // see Err
+}
+
+/*
+Holds an error for InvalidData behind a pointer, so that InvalidData stays
+comparable whatever the error's type.
+*/
+type keptError struct {
+	err error
+}
+
+/*
+Returns the error the message came from, if it was kept, e.g. one returned by a
+json.Unmarshaler, otherwise nil. See ValidationError.Unwrap.
+*/
+func (d InvalidData) Err() error {
+	if d.err == nil {
+		return nil
+	}
+	return d.err.err
+}
+
+/*
+Formats it as {path message}, without the kept error, which only matters to the
+server.
+*/
+func (d InvalidData) String() string {
+	return "{" + d.Path + " " + d.Error + "}"
 }
 
 type ValidationError []InvalidData
@@ -43,12 +80,47 @@ func (v ValidationError) Add(path, message string) ValidationError {
 	}
 	// capacity is there, so just resize
 	v = v[:len(v)+1]
-	v[len(v)-1] = InvalidData{path, message}
+	v[len(v)-1] = InvalidData{Path: path, Error: message}
+
+	return v
+}
 
+/*
+Same as Add, using err's message, but also keeps err so that it can be found
+with errors.Is and errors.As on the ValidationError.
+*/
+func (v ValidationError) AddErr(path string, err error) ValidationError {
+	v = v.Add(path, err.Error())
+	v[len(v)-1].err = &keptError{err}
 	return v
 }
 
+/*
+Returns the errors kept by AddErr, so that errors.Is and errors.As can find
+them, e.g. a custom error from an UnmarshalJSON method:
+
+	var perr *PhoneError
+	if errors.As(err, &perr) {
+		...
+	}
+*/
+func (v ValidationError) Unwrap() []error {
+	var errs []error
+	for _, e := range v {
+		if err := e.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
 func (v ValidationError) AddMany(o ValidationError) ValidationError {
+	// nested parsers return their own errors, so when we've none of our own we
+	// can take theirs over rather than copying them at every level
+	if len(v) == 0 {
+		return o
+	}
+
 	off := len(v)
 	if off+len(o) > cap(v) {
 		newCap := cap(v) + len(o) + cap(v)/2
@@ -64,13 +136,37 @@ func (v ValidationError) AddMany(o ValidationError) ValidationError {
 	return v
 }
 
+/*
+Groups the error messages by their path, e.g. for building a response that maps
+each field to its errors. Messages for each path are in the order they were
+found.
+*/
+func (v ValidationError) ByPath() map[string][]string {
+	m := make(map[string][]string, len(v))
+	for _, e := range v {
+		m[e.Path] = append(m[e.Path], e.Error)
+	}
+	return m
+}
+
 func NewSingleVErr(path, msg string) ValidationError {
-	return []InvalidData{{path, msg}}
+	return []InvalidData{{Path: path, Error: msg}}
 }
 
 type ValidatingParser struct {
 	targetType reflect.Type
 	schema     SchemaType
+
+	allowTrailingComma bool
+	allowLeadingPlus   bool
+	allowEmptyBody     bool
+	maxKeyLen          int
+	maxNumDigits       int
+	maxObjectKeys      int
+	maxDepth           int
+	maxElements        int
+	errSink            func(InvalidData) bool
+	bufSize            int // 0 for READ_LEN
 }
 
 /*
@@ -93,46 +189,351 @@ Same as Parser, but returns an error instead of panicing
 */
 func ParserError(t interface{}, s SchemaType) (*ValidatingParser, error) {
 	targetType := reflect.Indirect(reflect.ValueOf(t)).Type()
+	if err := prepareSchema(targetType, s); err != nil {
+		return nil, err
+	}
+	return &ValidatingParser{targetType: targetType, schema: s, maxDepth: DefaultMaxDepth}, nil
+}
+
+/*
+Prepares s for parsing into values of the same type as t, for use with
+Scanner.ParseWith. As with Parser, t can be a pointer to or direct instance of
+the type.
+
+This only needs to be done once per schema, Parser and ParserError do it for
+the schema they are given.
+*/
+func PrepareSchema(t interface{}, s SchemaType) error {
+	return prepareSchema(reflect.Indirect(reflect.ValueOf(t)).Type(), s)
+}
+
+func prepareSchema(t reflect.Type, s SchemaType) error {
 	if ps, ok := s.(PreparedSchemaType); ok {
-		if err := ps.Prepare(targetType); err != nil {
-			return nil, err
-		}
+		return ps.Prepare(t)
+	}
+	return nil
+}
+
+/*
+Sets whether the input may have trailing commas in objects and arrays. See
+Scanner.AllowTrailingComma.
+*/
+func (p *ValidatingParser) AllowTrailingComma(allow bool) *ValidatingParser {
+	p.allowTrailingComma = allow
+	return p
+}
+
+/*
+Treats an empty input, or one that's only whitespace, as an empty object, {},
+rather than a ParseError, for endpoints where an empty body means "use the
+defaults". Defaults are applied, and missing required props are reported, as
+they would be for {}.
+
+This only applies if the schema parses objects, e.g. Struct, any other schema
+still gets a ParseError.
+*/
+func (p *ValidatingParser) AllowEmptyBody() *ValidatingParser {
+	p.allowEmptyBody = true
+	return p
+}
+
+/*
+Sets whether numbers in the input may start with a '+'. See
+Scanner.AllowLeadingPlus.
+*/
+func (p *ValidatingParser) AllowLeadingPlus(allow bool) *ValidatingParser {
+	p.allowLeadingPlus = allow
+	return p
+}
+
+/*
+Limits the length of object keys in the input. See Scanner.MaxKeyLength.
+*/
+func (p *ValidatingParser) MaxKeyLength(n int) *ValidatingParser {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum key length must be >= 0"))
+	}
+	p.maxKeyLen = n
+	return p
+}
+
+/*
+Limits the number of keys in objects parsed into maps. See
+Scanner.MaxObjectKeys.
+*/
+func (p *ValidatingParser) MaxObjectKeys(n int) *ValidatingParser {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum number of object keys must be >= 0"))
+	}
+	p.maxObjectKeys = n
+	return p
+}
+
+/*
+Limits how deeply objects and arrays can be nested, including within values
+that are skipped. The default is DefaultMaxDepth, see Scanner.MaxDepth.
+*/
+func (p *ValidatingParser) MaxDepth(n int) *ValidatingParser {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum depth must be >= 0"))
+	}
+	p.maxDepth = n
+	return p
+}
+
+/*
+Limits the number of elements in any one array or object. See
+Scanner.MaxElements.
+*/
+func (p *ValidatingParser) MaxElements(n int) *ValidatingParser {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum number of elements must be >= 0"))
+	}
+	p.maxElements = n
+	return p
+}
+
+/*
+Sends validation errors to fn as they're found, rather than returning them all
+at the end. See Scanner.SetErrorSink.
+*/
+func (p *ValidatingParser) WithErrorSink(fn func(InvalidData) bool) *ValidatingParser {
+	p.errSink = fn
+	return p
+}
+
+/*
+Limits the number of digits in numbers in the input. See
+Scanner.MaxNumberDigits.
+*/
+func (p *ValidatingParser) MaxNumberDigits(n int) *ValidatingParser {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum number of digits must be >= 0"))
 	}
-	return &ValidatingParser{targetType, s}, nil
+	p.maxNumDigits = n
+	return p
+}
+
+/*
+Sets how many bytes are read from the input at a time, see NewScannerSize.
+*/
+func (p *ValidatingParser) BufferSize(n int) *ValidatingParser {
+	if n <= 0 {
+		panic(fmt.Errorf("Buffer size must be > 0"))
+	}
+	p.bufSize = n
+	return p
 }
 
 /*
 Parses, and validates b into the v.
 
+Returns a ValidationError if the input is well-formed JSON that doesn't match
+the schema, e.g. a string that's too short, a string where a number is expected
+or a missing required property, and a *ParseError if it isn't valid JSON at
+all, e.g. it's truncated or has a syntax error, after which nothing can be said
+about its contents. Any other error, e.g. from reading r, is returned as is.
+
 Will panic if b is not a pointer to the same type as was used to construct this
 parser.
 */
 func (p *ValidatingParser) Parse(r io.Reader, v interface{}) error {
-	// check the type is correct
+	p.checkDest(v)
+	return p.parse(p.configure(NewScanner(r)), v)
+}
+
+/*
+The Scanners used by ParsePooled. Ones whose buffer has grown past
+maxPooledBuf aren't kept, so that one huge input doesn't pin its memory.
+*/
+var scannerPool = sync.Pool{
+	New: func() interface{} { return NewScanner(nil) },
+}
+
+const maxPooledBuf = 64 * 1024
+
+/*
+Same as Parse, but borrows a Scanner, and its buffer, from a pool shared by all
+parsers rather than allocating a new one, which saves allocations when parsing
+lots of small inputs, e.g. request bodies. It's safe for concurrent use.
+
+This is the same as using ParseScanner with a Scanner that's Reset for each
+input, without having to manage the Scanners. The parser's options are applied
+to the Scanner each time.
+*/
+func (p *ValidatingParser) ParsePooled(r io.Reader, v interface{}) error {
+	p.checkDest(v)
+
+	s := scannerPool.Get().(*Scanner)
+	s.Reset(r)
+	err := p.parse(p.configure(s), v)
+
+	// don't keep hold of the reader, or anything from this parse
+	s.Reset(nil)
+	s.SetErrorSink(nil)
+	if cap(s.buf) <= maxPooledBuf {
+		scannerPool.Put(s)
+	}
+	return err
+}
+
+/*
+Same as Parse, but reads directly from b without copying it.
+
+Parsers that alias their input, e.g. RawBytesNoCopy, will return values that
+reference b when used via this method, so b must not be modified whilst those
+values are in use.
+*/
+func (p *ValidatingParser) ParseBytes(b []byte, v interface{}) error {
+	p.checkDest(v)
+	return p.parse(p.configure(NewBytesScanner(b)), v)
+}
+
+/*
+Same as Parse, but gives up with ErrTimeout if reading r takes longer than d.
+See Scanner.SetDeadline.
+*/
+func (p *ValidatingParser) ParseTimeout(d time.Duration, r io.Reader, v interface{}) error {
+	p.checkDest(v)
+	s := p.configure(NewScanner(r))
+	s.SetDeadline(time.Now().Add(d))
+	return p.parse(s, v)
+}
+
+/*
+Same as Parse, but gives up with ctx.Err() if ctx is done before r has been
+read. See Scanner.SetContext.
+*/
+func (p *ValidatingParser) ParseContext(ctx context.Context, r io.Reader, v interface{}) error {
+	p.checkDest(v)
+	s := p.configure(NewScanner(r))
+	s.SetContext(ctx)
+	return p.parse(s, v)
+}
+
+/*
+Same as Parse, but reads from s, a Scanner that has already been created and
+configured by the caller.
+
+The parser's own options, e.g. AllowTrailingComma, are not applied to s, it's
+used exactly as it's configured. Combined with Scanner.Reset, this allows a
+Scanner and its buffer to be re-used for many inputs.
+*/
+func (p *ValidatingParser) ParseScanner(s *Scanner, v interface{}) error {
+	p.checkDest(v)
+	return p.parse(s, v)
+}
+
+/*
+Checks that the JSON read from r is valid against the schema, returning the
+same errors as Parse would, but without storing the values anywhere. This is
+useful for services that only need to accept or reject input, e.g. a gateway
+that forwards the original bytes on.
+
+Schema types that implement ValidatingSchemaType, which includes Struct, Slice,
+String, Integer and Boolean, are checked without allocating anything to hold
+the values. Defaults aren't applied, as there's nowhere to apply them to.
+*/
+func (p *ValidatingParser) Validate(r io.Reader) error {
+	s := p.emptyBody(p.configure(NewScanner(r)))
+	return rootError(s, validateValue(p.schema, p.targetType, rootPath, s))
+}
+
+/*
+Same as Validate, but reads directly from b.
+*/
+func (p *ValidatingParser) ValidateBytes(b []byte) error {
+	s := p.emptyBody(p.configure(NewBytesScanner(b)))
+	return rootError(s, validateValue(p.schema, p.targetType, rootPath, s))
+}
+
+/*
+Applies the parser's options to a Scanner it has created.
+*/
+func (p *ValidatingParser) configure(s *Scanner) *Scanner {
+	s.AllowTrailingComma(p.allowTrailingComma)
+	s.AllowLeadingPlus(p.allowLeadingPlus)
+	s.MaxKeyLength(p.maxKeyLen)
+	s.MaxNumberDigits(p.maxNumDigits)
+	s.MaxObjectKeys(p.maxObjectKeys)
+	s.MaxDepth(p.maxDepth)
+	s.MaxElements(p.maxElements)
+	s.SetErrorSink(p.errSink)
+	s.rlen = p.bufSize
+	return s
+}
+
+/*
+Panics if v is not a pointer to the type this parser was built for.
+*/
+func (p *ValidatingParser) checkDest(v interface{}) {
 	// we must get a Ptr to same type as was given on creation
 	tPtr := reflect.TypeOf(v)
 	if tPtr.Kind() != reflect.Ptr || tPtr.Elem() != p.targetType {
 		panic(fmt.Errorf("Expected Ptr to \"%v\", got \"%v\"", p.targetType, tPtr))
 	}
+}
+
+func (p *ValidatingParser) parse(s *Scanner, v interface{}) error {
+	return parseRoot(p.schema, p.emptyBody(s), v)
+}
+
+var emptyObject = []byte("{}")
+
+/*
+Returns the Scanner to read the root value from, which is s unless s's input is
+empty and AllowEmptyBody applies, in which case it's one reading {}.
+*/
+func (p *ValidatingParser) emptyBody(s *Scanner) *Scanner {
+	if !p.allowEmptyBody || ExpectedTypeOf(p.schema) != JSONObject {
+		return s
+	}
+	if tok, err := s.PeekToken(); tok == TokenError && err == io.EOF {
+		return s.subScanner(emptyObject)
+	}
+	return s
+}
 
-	s := NewScanner(r)
+/*
+Parses a single root value from s with schema, converting any error into the
+form returned by ValidatingParser.Parse.
+*/
+func parseRoot(schema SchemaType, s *Scanner, v interface{}) error {
+	s.sunk = 0
+	return rootError(s, schema.Parse(rootPath, s, v))
+}
 
-	// the base pather
-	path := func() string {
-		return "/"
+/*
+Converts an error from parsing the root value from s into the form returned by
+ValidatingParser.Parse, sending any validation errors to s's error sink, if it
+has one.
+*/
+func rootError(s *Scanner, err error) error {
+	if err == ErrAbsent {
+		err = NewSingleVErr("/", ERROR_PROP_REQUIRED)
 	}
 
-	if err := p.schema.Parse(path, s, v); err != nil {
-		if verr, ok := err.(ValidationError); ok {
-			return verr
-		} else if perr, ok := err.(*ParseError); ok {
-			return NewSingleVErr("/", perr.Error())
-		} else if err == io.EOF {
-			return NewSingleVErr("/", "Unexpected end of input during parsing")
-		} else {
+	if verr, ok := err.(ValidationError); ok && s.errSink != nil {
+		if _, err := s.addErrors(nil, verr); err != nil {
 			return err
 		}
+		err = nil
 	}
 
-	return nil
+	if err == nil {
+		if s.sunk > 0 {
+			return ErrInvalid
+		}
+		return nil
+	} else if verr, ok := err.(ValidationError); ok {
+		return verr
+	} else if err == io.EOF {
+		return NewParseError(ERROR_UNEXPECTED_EOF)
+	}
+	return err
+}
+
+// the base pather
+func rootPath() string {
+	return "/"
 }
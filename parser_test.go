@@ -2,8 +2,13 @@ package jsonv
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type simpleStruct struct {
@@ -82,6 +87,12 @@ func Test_parserBadTypes(t *testing.T) {
 		// dest type props must have a type that each prop parser can map to
 		{Struct(Prop("Name", String())), new(intName)},
 
+		// props must not be ambiguous about which field they map to
+		{Struct(
+			Prop("NAME", String()),
+			Prop("name", String()),
+		), new(intName)},
+
 		// slices too!
 		{Slice(Struct(Prop("Name", String()))), make([]dumbStruct, 0, 10)},
 		{Slice(Struct(Prop("Name", String()))), make([]intName, 0, 10)},
@@ -93,3 +104,946 @@ func Test_parserBadTypes(t *testing.T) {
 		}
 	}
 }
+
+func Test_parserBadTypeMessages(t *testing.T) {
+	type Person struct {
+		Name int64
+	}
+	type Team struct {
+		Lead    Person
+		Members []Person
+	}
+
+	cases := []struct {
+		s    SchemaType
+		t    interface{}
+		want string
+	}{
+		{String(), new(int64), `want string destination, got int64`},
+		{Struct(Prop("Name", String())), new(Person), `prop "Name" on Person: want string destination, got int64`},
+		{Struct(Prop("Lead", Struct(Prop("Name", Boolean())))), new(Team), `prop "Lead" on Team: prop "Name" on Person: want bool destination, got int64`},
+		{Struct(Prop("Members", Slice(Struct(Prop("Name", Date()))))), new(Team), `prop "Members" on Team: prop "Name" on Person: want time.Time destination, got int64`},
+		{Struct(Prop("Name", Struct(Prop("X", Integer())))), new(struct{ Name struct{ X string } }), `prop "Name" on struct { Name struct { X string } }: prop "X" on struct { X string }: want integer destination, got string`},
+	}
+
+	for i, c := range cases {
+		if _, err := ParserError(c.t, c.s); err == nil {
+			t.Errorf("Case %d: Expected error, got nil", i)
+		} else if err.Error() != c.want {
+			t.Errorf("Case %d: Got %q, want %q", i, err, c.want)
+		}
+	}
+
+	// config errors are still config errors
+	_, err := ParserError(new(Team), Struct(Prop("Lead", Struct(Prop("NAME", Integer()), Prop("name", Integer())))))
+	if _, ok := err.(*SchemaConfigError); !ok {
+		t.Errorf("Got %T %v, want a SchemaConfigError", err, err)
+	} else if !strings.HasPrefix(err.Error(), `prop "Lead" on Team: `) {
+		t.Errorf("Got %q, want it to start with the prop", err)
+	}
+}
+
+func Test_parserAmbiguousProps(t *testing.T) {
+	type ider struct {
+		Id int64
+	}
+	type twoIds struct {
+		ID int64 `json:"ID"`
+		Id int64 `json:"id"`
+	}
+
+	cases := []struct {
+		s SchemaType
+		t interface{}
+	}{
+		// two props fold onto one field
+		{Struct(Prop("ID", Integer()), Prop("id", Integer())), new(ider)},
+		// the same prop twice
+		{Struct(Prop("Id", Integer()), Prop("Id", Integer())), new(ider)},
+		// one prop folds onto two fields
+		{Struct(Prop("iD", Integer())), new(twoIds)},
+	}
+
+	for i, c := range cases {
+		if _, err := ParserError(c.t, c.s); err == nil {
+			t.Errorf("Case %d: Expected error, got nil", i)
+		} else if _, ok := err.(*SchemaConfigError); !ok {
+			t.Errorf("Case %d: Got %T %v, want a *SchemaConfigError", i, err, err)
+		}
+	}
+
+	// exact matches are unambiguous
+	okCases := []SchemaType{
+		Struct(Prop("ID", Integer()), Prop("id", Integer())),
+		Struct(Prop("ID", Integer())),
+		Struct(Prop("id", Integer())),
+	}
+	for i, s := range okCases {
+		if _, err := ParserError(new(twoIds), s); err != nil {
+			t.Errorf("OK case %d: Got error %v, want nil", i, err)
+		}
+	}
+}
+
+func Test_ScannerBytesRead(t *testing.T) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String())))
+
+	// several values in one stream
+	s := NewScanner(bytes.NewBufferString(`{"Captcha": "a"}
+{"Captcha": "bb"} {"Captcha": "c"}`))
+	var got simpleStruct
+	for i, want := range []int{16, 34, 51} {
+		if err := parser.ParseScanner(s, &got); err != nil {
+			t.Fatal(err)
+		} else if n := s.BytesRead(); n != want {
+			t.Errorf("Value %d: Got %d bytes read, want %d", i, n, want)
+		}
+	}
+
+	s.Reset(bytes.NewBufferString(`{}`))
+	if n := s.BytesRead(); n != 0 {
+		t.Errorf("Got %d bytes read after Reset, want 0", n)
+	}
+}
+
+func Test_ParseScanner(t *testing.T) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String())))
+
+	// configured on the scanner, not the parser
+	s := NewScanner(bytes.NewBufferString(`{"Captcha": "Zing",}`))
+	s.AllowTrailingComma(true)
+
+	var got simpleStruct
+	if err := parser.ParseScanner(s, &got); err != nil {
+		t.Fatal(err)
+	} else if got.Captcha != "Zing" {
+		t.Fatalf("Got %v, want Zing", got.Captcha)
+	}
+
+	// re-use it, keeping its config
+	s.Reset(bytes.NewBufferString(`{"Captcha": "Zong",}`))
+	if err := parser.ParseScanner(s, &got); err != nil {
+		t.Fatal(err)
+	} else if got.Captcha != "Zong" {
+		t.Fatalf("Got %v, want Zong", got.Captcha)
+	}
+
+	// the parser's own options don't apply
+	s.Reset(bytes.NewBufferString(`{"Captcha": "Zing",}`))
+	s.AllowTrailingComma(false)
+	if err := parser.AllowTrailingComma(true).ParseScanner(s, &got); err == nil {
+		t.Fatal("Got no error, wanted one")
+	}
+}
+
+func Test_ParsePooled(t *testing.T) {
+	schema := Struct(Prop("Captcha", String(MinLen(2))), Prop("Fullname", String()))
+	parser := Parser(&simpleStruct{}, schema).AllowTrailingComma(true)
+
+	// many goroutines, each with their own scanner from the pool
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				name := fmt.Sprintf("%d-%d-%s", g, i, strings.Repeat("x", i))
+				var got simpleStruct
+				err := parser.ParsePooled(strings.NewReader(`{"Captcha": "Zing", "Fullname": "`+name+`",}`), &got)
+				if err != nil {
+					errs <- err
+					return
+				} else if want := (simpleStruct{"Zing", name}); got != want {
+					errs <- fmt.Errorf("Got %v, want %v", got, want)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	// errors are returned as normal, and the parser's options are applied
+	var got simpleStruct
+	err := parser.ParsePooled(strings.NewReader(`{"Captcha": "Z", "Fullname": "Bob"}`), &got)
+	if want := (ValidationError{{Path: "/Captcha", Error: "Must be at least 2 bytes long"}}); !reflect.DeepEqual(err, want) {
+		t.Fatalf("Got %v, want %v", err, want)
+	}
+	err = Parser(&got, schema).ParsePooled(strings.NewReader(`{"Captcha": "Zing", "Fullname": "Bob",}`), &got)
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got %v, want a ParseError", err)
+	}
+
+	// an error sink is used only by the parser it was set on
+	var sunk []InvalidData
+	sinking := Parser(&got, schema).WithErrorSink(func(e InvalidData) bool {
+		sunk = append(sunk, e)
+		return true
+	})
+	if err := sinking.ParsePooled(strings.NewReader(`{"Captcha": "Z", "Fullname": "Bob"}`), &got); err != ErrInvalid {
+		t.Fatalf("Got %v, want ErrInvalid", err)
+	}
+	err = parser.ParsePooled(strings.NewReader(`{"Captcha": "Z", "Fullname": "Bob"}`), &got)
+	if _, ok := err.(ValidationError); !ok || len(sunk) != 1 {
+		t.Fatalf("Got %v and %d sunk, want a ValidationError and 1 sunk", err, len(sunk))
+	}
+}
+
+func Test_ParserAllowEmptyBody(t *testing.T) {
+	type options struct {
+		Limit int64
+		Sort  string
+		Owner *string
+	}
+	schema := Struct(
+		PropWithDefault("Limit", Integer(), int64(10)),
+		PropWithDefault("Sort", String(), "name"),
+		Prop("Owner", String()),
+	)
+	parser := Parser(&options{}, schema).AllowEmptyBody()
+
+	for _, body := range []string{"", " \n\t "} {
+		var got options
+		if err := parser.Parse(strings.NewReader(body), &got); err != nil {
+			t.Fatalf("%q: Got error %v, want nil", body, err)
+		} else if want := (options{10, "name", nil}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: Got %v, want %v", body, got, want)
+		}
+		if err := parser.ValidateBytes([]byte(body)); err != nil {
+			t.Fatalf("%q: Got error %v from ValidateBytes, want nil", body, err)
+		}
+	}
+
+	// it's only an empty object, so required props are still required
+	type named struct {
+		Name  string
+		Limit int64
+	}
+	var got named
+	err := Parser(&got, Struct(Prop("Name", String()), PropWithDefault("Limit", Integer(), int64(10)))).AllowEmptyBody().Parse(strings.NewReader(""), &got)
+	if want := (ValidationError{{Path: "/Name", Error: "Required"}}); !reflect.DeepEqual(err, want) {
+		t.Fatalf("Got %v, want %v", err, want)
+	}
+
+	// without the option, or for a schema that doesn't take objects, it's an error
+	var opts options
+	if _, ok := Parser(&opts, schema).Parse(strings.NewReader(""), &opts).(*ParseError); !ok {
+		t.Fatalf("Got no ParseError without AllowEmptyBody")
+	}
+	var ints []int64
+	if _, ok := Parser(&ints, Slice(Integer())).AllowEmptyBody().Parse(strings.NewReader(""), &ints).(*ParseError); !ok {
+		t.Fatalf("Got no ParseError for a Slice")
+	}
+}
+
+func Test_ValidationErrorByPath(t *testing.T) {
+	var errs ValidationError
+	errs = errs.Add("/Name", "Required")
+	errs = errs.Add("/Age", "Must be greater than or equal to 0")
+	errs = errs.Add("/Age", "Must be a multiple of 2")
+	errs = errs.Add("/Friends/1/", "Must be at least 1 characters long")
+
+	want := map[string][]string{
+		"/Name":       {"Required"},
+		"/Age":        {"Must be greater than or equal to 0", "Must be a multiple of 2"},
+		"/Friends/1/": {"Must be at least 1 characters long"},
+	}
+	if got := errs.ByPath(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Got %v, want %v", got, want)
+	}
+}
+
+func Test_ParserMaxKeyLength(t *testing.T) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String()))).MaxKeyLength(7)
+
+	var got simpleStruct
+	if err := parser.Parse(bytes.NewBufferString(`{"Captcha": "Zing"}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse(bytes.NewBufferString(`{"Captcha": "Zing", "Fullname": "Bob"}`), &got); err == nil {
+		t.Fatal("Got no error, wanted one")
+	}
+}
+
+func Test_ParserMaxNumberDigits(t *testing.T) {
+	parser := Parser(new(int64), Integer()).MaxNumberDigits(6)
+
+	var got int64
+	if err := parser.Parse(bytes.NewBufferString(`999999`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Parse(bytes.NewBufferString(`1000000`), &got); err == nil {
+		t.Fatal("Got no error, wanted one")
+	} else if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got %v, want a ParseError", err)
+	}
+}
+
+func Test_ParserMaxObjectKeys(t *testing.T) {
+	type pair struct {
+		Key   string
+		Value int64
+	}
+
+	cases := []struct {
+		dest    interface{}
+		s       SchemaType
+		json    string
+		isValid bool
+	}{
+		{new(map[string]int64), Map(Integer()), `{"a": 1, "b": 2, "c": 3}`, true},
+		{new(map[string]int64), Map(Integer()), `{"a": 1, "b": 2, "c": 3, "d": 4}`, false},
+		{new(map[string]int64), Map(Integer()), `{"a": 1, "a": 2, "a": 3, "a": 4}`, false},
+		{new([]pair), Pairs(Integer()), `{"a": 1, "b": 2, "c": 3, "d": 4}`, false},
+		{new([]map[string]int64), Slice(Map(Integer())), `[{"a": 1, "b": 2, "c": 3}, {"d": 4, "e": 5, "f": 6}]`, true},
+		{new(int64), Integer(), `1`, true},
+	}
+
+	for i, c := range cases {
+		err := Parser(c.dest, c.s).MaxObjectKeys(3).Parse(bytes.NewBufferString(c.json), c.dest)
+		if c.isValid && err != nil {
+			t.Errorf("Case %d: Got error %v, want nil", i, err)
+		} else if !c.isValid {
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("Case %d: Got %v, want a ParseError", i, err)
+			}
+		}
+	}
+
+	// a huge object is stopped at the limit
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&buf, `"k%d": %d, `, i, i)
+	}
+	got := map[string]int64{}
+	err := Parser(&got, Map(Integer())).MaxObjectKeys(10).Parse(&buf, &got)
+	if want := "Object has more than 10 keys, at byte 91"; err == nil || err.Error() != want {
+		t.Fatalf("Got %v, want %v", err, want)
+	} else if len(got) != 10 {
+		t.Fatalf("Got %d keys, want 10", len(got))
+	}
+}
+
+func Test_ParserMaxElements(t *testing.T) {
+	type shallow struct {
+		Name string
+	}
+
+	cases := []struct {
+		dest    interface{}
+		s       SchemaType
+		json    string
+		isValid bool
+	}{
+		{new([]int64), Slice(Integer()), `[1, 2, 3]`, true},
+		{new([]int64), Slice(Integer()), `[1, 2, 3, 4]`, false},
+		{new([][]int64), Slice(Slice(Integer())), `[[1, 2, 3], [4, 5, 6], [7, 8, 9]]`, true},
+		{new(map[string]int64), Map(Integer()), `{"a": 1, "b": 2, "c": 3, "d": 4}`, false},
+		{new(shallow), Struct(Prop("Name", String())), `{"Name": "a", "b": 2, "c": 3}`, true},
+		{new(shallow), Struct(Prop("Name", String())), `{"Name": "a", "b": 2, "c": 3, "d": 4}`, false},
+		{new(shallow), Struct(Prop("Name", String())), `{"Name": "a", "b": [1, 2, 3, 4]}`, false},
+		{new(shallow), Struct(Prop("Name", String())), `{"Name": "a", "b": {"a": 1, "b": 2, "c": 3, "d": 4}}`, false},
+		{new(interface{}), OneOf("type", map[string]OneOfCase{"a": Case(shallow{}, Struct(Prop("Name", String())))}), `{"type": "a", "Name": "a", "b": [1, 2, 3, 4]}`, false},
+		{new(shallow), StructPositional(Prop("Name", String())), `["a"]`, true},
+		{new(shallow), StructPositional(Prop("Name", String())), `["a", 2, 3, 4, 5, 6, 7, 8, 9]`, false},
+	}
+
+	for i, c := range cases {
+		err := Parser(c.dest, c.s).MaxElements(3).Parse(bytes.NewBufferString(c.json), c.dest)
+		if c.isValid && err != nil {
+			t.Errorf("Case %d: Got error %v, want nil", i, err)
+		} else if !c.isValid {
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("Case %d: Got %v, want a ParseError", i, err)
+			}
+		}
+	}
+
+	// a huge array or object is stopped at the limit, whether parsed or skipped
+	huge := func(open, elem, close string) string {
+		var buf bytes.Buffer
+		buf.WriteString(open)
+		for i := 0; i < 100000; i++ {
+			fmt.Fprintf(&buf, elem, i)
+		}
+		buf.WriteString(close)
+		return buf.String()
+	}
+	var ints []int64
+	err := Parser(&ints, Slice(Integer())).MaxElements(10).Parse(strings.NewReader(huge("[", "%d, ", "0]")), &ints)
+	if want := "Array or object has more than 10 elements, at byte 31"; err == nil || err.Error() != want {
+		t.Fatalf("Got %v, want %v", err, want)
+	}
+
+	var pos shallow
+	err = Parser(&pos, StructPositional(Prop("Name", String()))).MaxElements(10).Parse(strings.NewReader(huge(`["a", `, `%d, `, `0]`)), &pos)
+	if want := "Array or object has more than 10 elements, at byte 33"; err == nil || err.Error() != want {
+		t.Fatalf("Got %v, want %v", err, want)
+	}
+
+	var got shallow
+	err = Parser(&got, Struct(Prop("Name", String()))).MaxElements(10).Parse(strings.NewReader(huge(`{"Name": "a", `, `"k%d": 1, `, `"z": 1}`)), &got)
+	if want := "Array or object has more than 10 elements, at byte 95"; err == nil || err.Error() != want {
+		t.Fatalf("Got %v, want %v", err, want)
+	}
+}
+
+func Test_ParserMaxDepth(t *testing.T) {
+	type shallow struct {
+		Name string
+	}
+
+	// nest n arrays in an unknown prop
+	nested := func(n int) string {
+		return `{"Name": "a", "Extra": ` + strings.Repeat("[", n) + strings.Repeat("]", n) + `}`
+	}
+
+	cases := []struct {
+		dest    interface{}
+		s       SchemaType
+		json    string
+		isValid bool
+	}{
+		{new(shallow), Struct(Prop("Name", String())), nested(2), true},
+		{new(shallow), Struct(Prop("Name", String())), nested(3), false},
+		{new(shallow), Struct(Prop("Name", String())), `{"Name": "a", "Extra": {"a": {"b": {}}}}`, false},
+		{new([][]int64), Slice(Slice(Integer())), `[[1], [2, 3]]`, true},
+		{new([][][]int64), Slice(Slice(Slice(Integer()))), `[[[1]]]`, true},
+		{new([][][][]int64), Slice(Slice(Slice(Slice(Integer())))), `[[[[1]]]]`, false},
+		{new(map[string]shallow), Map(Struct(Prop("Name", String()))), `{"a": {"Name": "a", "Extra": [[]]}}`, false},
+		{new(interface{}), OneOf("type", map[string]OneOfCase{"a": Case(shallow{}, Struct(Prop("Name", String())))}), `{"type": "a", "Name": "a", "Extra": [[[]]]}`, false},
+		{new([][]shallow), Slice(Slice(StructPositional(Prop("Name", String())))), `[[["a"]]]`, true},
+		{new([][][]shallow), Slice(Slice(Slice(StructPositional(Prop("Name", String()))))), `[[[["a"]]]]`, false},
+	}
+
+	for i, c := range cases {
+		err := Parser(c.dest, c.s).MaxDepth(3).Parse(bytes.NewBufferString(c.json), c.dest)
+		if c.isValid && err != nil {
+			t.Errorf("Case %d: Got error %v, want nil", i, err)
+		} else if !c.isValid {
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("Case %d: Got %v, want a ParseError", i, err)
+			}
+		}
+	}
+
+	// a hostile unknown prop is stopped at the limit, not skipped
+	var got shallow
+	err := Parser(&got, Struct(Prop("Name", String()))).MaxDepth(32).Parse(bytes.NewBufferString(nested(1000000)), &got)
+	if want := "Nested more than 32 deep, at byte 54"; err == nil || err.Error() != want {
+		t.Fatalf("Got %v, want %v", err, want)
+	}
+
+	// and there's always a limit by default
+	deep := nested(DefaultMaxDepth + 1)
+	err = Parser(&got, Struct(Prop("Name", String()))).Parse(bytes.NewBufferString(deep), &got)
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got %v, want a ParseError", err)
+	}
+	if err := NewScanner(bytes.NewBufferString(deep)).SkipValue(); err == nil {
+		t.Fatalf("Got no error skipping, wanted one")
+	}
+	if err := Parser(&got, Struct(Prop("Name", String()))).MaxDepth(0).Parse(bytes.NewBufferString(deep), &got); err != nil {
+		t.Fatalf("Got %v with no limit, want nil", err)
+	}
+}
+
+func Test_ParserErrorSink(t *testing.T) {
+	type item struct {
+		Name string
+		Qty  int64
+	}
+	type order struct {
+		Id    int64
+		Items []item
+		Notes map[string]string
+	}
+	schema := Struct(
+		Prop("Id", Integer(MinI(1))),
+		Prop("Items", Slice(Struct(Prop("Name", String(MinLen(1))), Prop("Qty", Integer(MinI(1)))), MaxItems(3))),
+		Prop("Notes", Map(String(MaxLen(5)))),
+	)
+	invalid := `{
+		"Id": 0,
+		"Items": [{"Name": "", "Qty": 0}, {"Name": "a", "Qty": 1}, {"Qty": 2}, {"Name": "b", "Qty": -1}],
+		"Notes": {"a": "too long"}
+	}`
+
+	// the sink gets the same errors, in the same order, as are returned
+	// without one
+	var got order
+	want := Parser(&got, schema).Parse(bytes.NewBufferString(invalid), &got)
+	if _, ok := want.(ValidationError); !ok {
+		t.Fatalf("Got %v, want a ValidationError", want)
+	}
+
+	var sunk ValidationError
+	parser := Parser(&got, schema).WithErrorSink(func(e InvalidData) bool {
+		sunk = append(sunk, e)
+		return true
+	})
+	if err := parser.Parse(bytes.NewBufferString(invalid), &got); err != ErrInvalid {
+		t.Fatalf("Got %v, want ErrInvalid", err)
+	} else if !reflect.DeepEqual(sunk, want) {
+		t.Fatalf("Got %v, want %v", sunk, want)
+	}
+
+	// as does validating
+	sunk = nil
+	if err := parser.ValidateBytes([]byte(invalid)); err != ErrInvalid {
+		t.Fatalf("Got %v, want ErrInvalid", err)
+	} else if !reflect.DeepEqual(sunk, want) {
+		t.Fatalf("Got %v from Validate, want %v", sunk, want)
+	}
+
+	// valid input is still fine
+	sunk = nil
+	if err := parser.Parse(bytes.NewBufferString(`{"Id": 1, "Items": [], "Notes": {}}`), &got); err != nil {
+		t.Fatalf("Got %v, want nil", err)
+	} else if len(sunk) != 0 {
+		t.Fatalf("Got %v, want no errors", sunk)
+	}
+
+	// returning false stops straight away
+	sunk = nil
+	parser = Parser(&got, schema).WithErrorSink(func(e InvalidData) bool {
+		sunk = append(sunk, e)
+		return len(sunk) < 2
+	})
+	if err := parser.Parse(bytes.NewBufferString(invalid), &got); err != ErrStopped {
+		t.Fatalf("Got %v, want ErrStopped", err)
+	} else if !reflect.DeepEqual(sunk, want.(ValidationError)[:2]) {
+		t.Fatalf("Got %v, want %v", sunk, want.(ValidationError)[:2])
+	}
+
+	// a bare value's errors are sent too
+	sunk = nil
+	intParser := Parser(new(int64), Integer(MinI(1))).WithErrorSink(func(e InvalidData) bool {
+		sunk = append(sunk, e)
+		return true
+	})
+	if err := intParser.Parse(bytes.NewBufferString(`0`), new(int64)); err != ErrInvalid {
+		t.Fatalf("Got %v, want ErrInvalid", err)
+	} else if len(sunk) != 1 || sunk[0].Path != "/" {
+		t.Fatalf("Got %v, want one error for /", sunk)
+	}
+}
+
+func Test_ParserErrorTypes(t *testing.T) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String(MinLen(4))), Prop("Fullname", String())))
+
+	// valid JSON that fails validation
+	invalid := []string{
+		`{"Captcha": "Zin", "Fullname": "Bob"}`,
+		`{"Captcha": "Zing"}`,
+		`{"Captcha": 12, "Fullname": "Bob"}`,
+	}
+	for i, json := range invalid {
+		var got simpleStruct
+		if err := parser.Parse(bytes.NewBufferString(json), &got); err == nil {
+			t.Errorf("Invalid case %d: Got no error, wanted one", i)
+		} else if _, ok := err.(ValidationError); !ok {
+			t.Errorf("Invalid case %d: Got %T %v, want a ValidationError", i, err, err)
+		}
+	}
+
+	// input that isn't JSON
+	malformed := []string{
+		``,
+		`{"Captcha": "Zing", "Fullname": "Bob"`,
+		`{"Captcha": "Zing" "Fullname": "Bob"}`,
+		`{"Captcha": "Zing", "Fullname": Bob}`,
+		`["Zing", "Bob"]`,
+	}
+	for i, json := range malformed {
+		var got simpleStruct
+		if err := parser.Parse(bytes.NewBufferString(json), &got); err == nil {
+			t.Errorf("Malformed case %d: Got no error, wanted one", i)
+		} else if _, ok := err.(*ParseError); !ok {
+			t.Errorf("Malformed case %d: Got %T %v, want a ParseError", i, err, err)
+		}
+	}
+}
+
+func Test_ParserTypeMismatch(t *testing.T) {
+	type person struct {
+		Name   string
+		Age    int64
+		Height float64
+		Active bool
+		Born   time.Time
+		Avatar []byte
+		Email  string
+	}
+	parser := Parser(&person{}, Struct(
+		Prop("Name", String()),
+		Prop("Age", Integer()),
+		Prop("Height", Float()),
+		Prop("Active", Boolean()),
+		Prop("Born", Date()),
+		Prop("Avatar", Bytes()),
+		Prop("Email", String()),
+	))
+
+	// every field but the last has a value of the wrong type, including some
+	// objects and arrays that have to be skipped over
+	json := `{
+		"Name": {"first": "Bob", "last": ["Smith"]},
+		"Age": "twenty",
+		"Height": [1, [2, 3]],
+		"Active": 1,
+		"Born": 19700101,
+		"Avatar": null,
+		"Email": "bob@example.com"
+	}`
+
+	var got person
+	err := parser.Parse(bytes.NewBufferString(json), &got)
+	verr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Got %T %v, want a ValidationError", err, err)
+	}
+
+	wantPaths := []string{"/Name", "/Age", "/Height", "/Active", "/Born", "/Avatar"}
+	if len(verr) != len(wantPaths) {
+		t.Fatalf("Got %v, want errors for %v", verr, wantPaths)
+	}
+	for i, e := range verr {
+		if e.Path != wantPaths[i] {
+			t.Errorf("Error %d: Got path %v, want %v", i, e.Path, wantPaths[i])
+		}
+	}
+	if want := `Expected an integer, got "twenty"`; verr[1].Error != want {
+		t.Errorf("Got %q, want %q", verr[1].Error, want)
+	}
+
+	// the rest of the object is still parsed
+	if got.Email != "bob@example.com" {
+		t.Errorf("Got Email %q, want bob@example.com", got.Email)
+	}
+
+	// a malformed value is still malformed
+	err = parser.Parse(bytes.NewBufferString(`{"Age": {"a" 1}}`), &got)
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("Got %T %v, want a ParseError", err, err)
+	}
+}
+
+func Test_ParserValidate(t *testing.T) {
+	type friend struct {
+		Name string
+		Age  *int64
+	}
+	type person struct {
+		Name    string
+		Age     int64
+		Active  bool
+		Tags    []string
+		Friends []friend
+		Best    *friend
+		Pos     simpleStruct
+		Joined  time.Time
+		Colour  string
+	}
+
+	friendSchema := Struct(Prop("Name", String(MinLen(1))), Prop("Age", Integer(MinI(0))))
+	parser := Parser(&person{}, Struct(
+		Prop("Name", String(MinLen(2), MaxLen(10))),
+		PropWithDefault("Age", Integer(MinI(0), MaxI(150)), int64(-1)),
+		Prop("Active", Boolean()),
+		Prop("Tags", Slice(String(MinLen(1)), MaxItems(2))),
+		Prop("Friends", Slice(friendSchema, SliceValidatorFunc(func(v reflect.Value) error {
+			if v.Len() > 0 && v.Index(0).Field(0).String() == "Bob" {
+				return fmt.Errorf("Bob can't be first")
+			}
+			return nil
+		}))),
+		Prop("Best", friendSchema),
+		Prop("Pos", StructPositional(Prop("Captcha", String()), Prop("Fullname", String(MinLen(1))))),
+		Prop("Joined", Date()),
+		Prop("Colour", Enum(String(), "red", "blue")),
+	))
+
+	cases := []string{
+		`{"Name": "Al", "Active": true, "Tags": [], "Friends": [], "Best": {"Name": "Jo"}, "Pos": ["a", "b"], "Joined": "2020-01-02", "Colour": "red"}`,
+		`{"Name": "A", "Age": 200, "Active": "yes", "Tags": ["", "a", "b"], "Friends": [{"Name": "", "Age": -1}], "Best": {}, "Pos": ["a", ""], "Joined": "nope", "Colour": "green"}`,
+		`{"Name": "Al", "Active": true, "Tags": ["a"], "Friends": [{"Name": "Bob"}, {"Name": "Al"}], "Best": {"Name": "Jo"}, "Pos": ["a", "b", "c"], "Joined": "2020-01-02", "Colour": "blue"}`,
+		`{"Name": "Al"}`,
+		`{"Name": "Al", "Tags": ["a" "b"]}`,
+		`{"Name": "Al", "Age": 1.5}`,
+		`[]`,
+		``,
+	}
+
+	for i, json := range cases {
+		var dest person
+		want := parser.Parse(bytes.NewBufferString(json), &dest)
+		got := parser.Validate(bytes.NewBufferString(json))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Case %d: Got %v, want %v", i, got, want)
+		}
+		if got := parser.ValidateBytes([]byte(json)); !reflect.DeepEqual(got, want) {
+			t.Errorf("Case %d: Got %v from bytes, want %v", i, got, want)
+		}
+	}
+}
+
+/*
+Returns a byte at a time, waiting before each.
+*/
+type slowReader struct {
+	r     *bytes.Reader
+	delay time.Duration
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	time.Sleep(s.delay)
+	return s.r.Read(p[:1])
+}
+
+func Test_ParseTimeout(t *testing.T) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String()), Prop("Fullname", String())))
+	json := []byte(`{"Captcha": "Zing", "Fullname": "Bob"}`)
+
+	var got simpleStruct
+	slow := &slowReader{bytes.NewReader(json), 5 * time.Millisecond}
+	err := parser.ParseTimeout(20*time.Millisecond, slow, &got)
+	if err != ErrTimeout {
+		t.Fatalf("Got %v, want ErrTimeout", err)
+	} else if _, ok := err.(ValidationError); ok {
+		t.Fatalf("Got a ValidationError, want ErrTimeout")
+	} else if _, ok := err.(*ParseError); ok {
+		t.Fatalf("Got a ParseError, want ErrTimeout")
+	}
+
+	// plenty of time
+	got = simpleStruct{}
+	if err := parser.ParseTimeout(time.Minute, bytes.NewReader(json), &got); err != nil {
+		t.Fatal(err)
+	} else if want := (simpleStruct{"Zing", "Bob"}); got != want {
+		t.Fatalf("Got %v, want %v", got, want)
+	}
+}
+
+func Test_ParseContext(t *testing.T) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String()), Prop("Fullname", String())))
+	json := []byte(`{"Captcha": "Zing", "Fullname": "Bob"}`)
+
+	var got simpleStruct
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	slow := &slowReader{bytes.NewReader(json), 5 * time.Millisecond}
+	if err := parser.ParseContext(ctx, slow, &got); err != context.Canceled {
+		t.Fatalf("Got %v, want context.Canceled", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	slow = &slowReader{bytes.NewReader(json), 5 * time.Millisecond}
+	if err := parser.ParseContext(ctx, slow, &got); err != context.DeadlineExceeded {
+		t.Fatalf("Got %v, want context.DeadlineExceeded", err)
+	}
+
+	got = simpleStruct{}
+	if err := parser.ParseContext(context.Background(), bytes.NewReader(json), &got); err != nil {
+		t.Fatal(err)
+	} else if want := (simpleStruct{"Zing", "Bob"}); got != want {
+		t.Fatalf("Got %v, want %v", got, want)
+	}
+}
+
+var benchDocs = func() []string {
+	docs := make([]string, 100)
+	for i := range docs {
+		docs[i] = fmt.Sprintf(`{"Captcha": "Zing%d", "Fullname": "Bob Smith the %dth"}`, i, i)
+	}
+	return docs
+}()
+
+func BenchmarkParse(b *testing.B) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String()), Prop("Fullname", String())))
+	b.ReportAllocs()
+
+	var got simpleStruct
+	for i := 0; i < b.N; i++ {
+		if err := parser.Parse(strings.NewReader(benchDocs[i%len(benchDocs)]), &got); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParsePooled(b *testing.B) {
+	parser := Parser(&simpleStruct{}, Struct(Prop("Captcha", String()), Prop("Fullname", String())))
+	b.ReportAllocs()
+
+	var got simpleStruct
+	for i := 0; i < b.N; i++ {
+		if err := parser.ParsePooled(strings.NewReader(benchDocs[i%len(benchDocs)]), &got); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseInt64Slice(b *testing.B) {
+	data := []byte("[" + strings.TrimSuffix(strings.Repeat("1234567,", 100000), ",") + "]")
+	parser := Parser([]int64{}, Slice(Integer()))
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+
+	for i := 0; i < b.N; i++ {
+		var dest []int64
+		if err := parser.Parse(bytes.NewReader(data), &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseStringSlice(b *testing.B) {
+	data := []byte("[" + strings.TrimSuffix(strings.Repeat(`"Angelo",`, 100000), ",") + "]")
+	parser := Parser([]string{}, Slice(String()))
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+
+	for i := 0; i < b.N; i++ {
+		var dest []string
+		if err := parser.Parse(bytes.NewReader(data), &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseScatteredErrors(b *testing.B) {
+	type person struct {
+		Name    string
+		Age     int64
+		Friends []string
+	}
+	parser := Parser([]person{}, Slice(Struct(
+		Prop("Name", String(MinLen(1))),
+		Prop("Age", Integer(MinI(0))),
+		Prop("Friends", Slice(String(MinLen(1)))),
+	)))
+
+	docs := make([]string, 1024)
+	for i := range docs {
+		if i%2 == 0 {
+			docs[i] = `{"Name": "","Age":-1,"Friends":["","Jim",""]}`
+		} else {
+			docs[i] = `{"Name": "Angelo","Age":24,"Friends":["Bob","Jim","Jenny"]}`
+		}
+	}
+	data := []byte("[" + strings.Join(docs, ",") + "]")
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+
+	for i := 0; i < b.N; i++ {
+		var dest []person
+		err := parser.Parse(bytes.NewReader(data), &dest)
+		if verr, ok := err.(ValidationError); !ok || len(verr) != 512*4 {
+			b.Fatalf("Got %v, want %d errors", err, 512*4)
+		}
+	}
+}
+
+func BenchmarkParseEmptyCollections(b *testing.B) {
+	type item struct {
+		Id   *int64
+		Tags []string
+	}
+	type page struct {
+		Items  []item
+		Groups [][]int64
+		Meta   item
+		More   *item
+	}
+	schema := Struct(
+		Prop("Items", Slice(Struct(Prop("Id", Integer()), Prop("Tags", Slice(String()))))),
+		Prop("Groups", Slice(Slice(Integer()))),
+		Prop("Meta", Struct(Prop("Id", Integer()), Prop("Tags", Slice(String())))),
+		Prop("More", Struct(Prop("Id", Integer()), Prop("Tags", Slice(String())))),
+	)
+	doc := []byte(`{
+		"Items": [{"Tags": []}, {"Tags": []}, {"Tags": []}, {"Tags": []}],
+		"Groups": [[], [], [], [], [], []],
+		"Meta": {"Tags": []},
+		"More": {"Tags": []}
+	}`)
+	parser := Parser(&page{}, schema)
+	b.ReportAllocs()
+
+	var got page
+	for i := 0; i < b.N; i++ {
+		if err := parser.ParseBytes(doc, &got); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseLarge(b *testing.B) {
+	type doc struct {
+		Items []simpleStruct
+	}
+	items := make([]string, 10000)
+	for i := range items {
+		items[i] = benchDocs[i%len(benchDocs)]
+	}
+	json := `{"Items": [` + strings.Join(items, ", ") + `]}`
+
+	for _, size := range []int{READ_LEN, 4 << 10, 32 << 10} {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			parser := Parser(&doc{}, Struct(Prop("Items", Slice(Struct(Prop("Captcha", String()), Prop("Fullname", String())))))).BufferSize(size)
+			b.SetBytes(int64(len(json)))
+			b.ReportAllocs()
+
+			var got doc
+			for i := 0; i < b.N; i++ {
+				if err := parser.Parse(strings.NewReader(json), &got); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkValidateLarge(b *testing.B) {
+	type person struct {
+		Name    string
+		Age     int64
+		Friends []string
+	}
+	parser := Parser([]person{}, Slice(Struct(
+		Prop("Name", String()),
+		Prop("Age", Integer()),
+		Prop("Friends", Slice(String())),
+	)))
+	doc := `{"Name": "Angelo","Age":24,"Friends":["Bob","Jim","Jenny"]}`
+	data := []byte("[" + strings.TrimSuffix(strings.Repeat(doc+",", 1024), ",") + "]")
+
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			var dest []person
+			if err := parser.Parse(bytes.NewReader(data), &dest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Validate", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if err := parser.Validate(bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
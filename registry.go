@@ -0,0 +1,52 @@
+package jsonv
+
+import (
+	"reflect"
+	"sync"
+)
+
+var registry = struct {
+	sync.RWMutex
+	types map[reflect.Type]func() SchemaType
+}{types: make(map[reflect.Type]func() SchemaType)}
+
+/*
+Registers the SchemaType to use for values of type t wherever a schema isn't
+given explicitly, i.e. by Auto and for Struct props with a nil schema:
+
+	jsonv.RegisterType(reflect.TypeOf(uuid.UUID{}), func() jsonv.SchemaType {
+		return jsonv.String(jsonv.Pattern(uuidRe, "Must be a UUID"))
+	})
+
+	schema := jsonv.Struct(jsonv.Prop("Id", nil), jsonv.Prop("Name", jsonv.String()))
+
+fn is called for each use so that SchemaTypes with state, e.g. anything
+implementing PreparedSchemaType, are never shared. Registering a type again
+replaces the previous registration.
+
+This is usually called from an init func.
+*/
+func RegisterType(t reflect.Type, fn func() SchemaType) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if fn == nil {
+		delete(registry.types, t)
+	} else {
+		registry.types[t] = fn
+	}
+}
+
+/*
+Returns a new instance of the SchemaType registered for t, if there is one.
+*/
+func registeredSchema(t reflect.Type) (SchemaType, bool) {
+	registry.RLock()
+	fn, ok := registry.types[t]
+	registry.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+	return fn(), true
+}
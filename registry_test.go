@@ -0,0 +1,69 @@
+package jsonv
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// an id sent as a string like "id-42"
+type registryID int64
+
+type registryIDParser struct{}
+
+func (p registryIDParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	var str string
+	if err := String().Parse(path, s, &str); err != nil {
+		return err
+	}
+
+	var errs ValidationError
+	n, err := strconv.ParseInt(strings.TrimPrefix(str, "id-"), 10, 64)
+	if err != nil || !strings.HasPrefix(str, "id-") {
+		return errs.Add(path(), "Not an id")
+	}
+
+	*v.(*registryID) = registryID(n)
+	return nil
+}
+
+func Test_RegisterType(t *testing.T) {
+	type widget struct {
+		Id     registryID
+		Parent *registryID
+		Name   string
+	}
+
+	idType := reflect.TypeOf(registryID(0))
+	RegisterType(idType, func() SchemaType { return registryIDParser{} })
+	defer RegisterType(idType, nil)
+
+	schema := Struct(Prop("Id", nil), Prop("Parent", nil), Prop("Name", String()))
+
+	var got widget
+	want := widget{Id: 42, Parent: new(registryID), Name: "w"}
+	*want.Parent = 7
+	if err := tryParse(schema, `{"Id": "id-42", "Parent": "id-7", "Name": "w"}`, &got, want); err != nil {
+		t.Fatal(err)
+	}
+
+	// as does Auto
+	got = widget{}
+	if err := tryParse(Auto(), `{"Id": "id-42", "Parent": "id-7", "Name": "w"}`, &got, want); err != nil {
+		t.Fatal(err)
+	}
+
+	// errors from the registered parser are reported as normal
+	err := tryParse(schema, `{"Id": "42", "Name": "w"}`, &widget{}, widget{})
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/Id" {
+		t.Fatalf("Got %v, want a ValidationError for /Id", err)
+	}
+
+	// without a registration there's nothing to use
+	RegisterType(idType, nil)
+	err = Struct(Prop("Id", nil)).Prepare(reflect.TypeOf(widget{}))
+	if _, ok := err.(*SchemaConfigError); !ok {
+		t.Fatalf("Got %v, want a SchemaConfigError", err)
+	}
+}
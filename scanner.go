@@ -1,9 +1,12 @@
 package jsonv
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"strconv"
+	"time"
 	"unicode"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -14,6 +17,12 @@ const TOK_TRUE = "true"
 const TOK_FALSE = "false"
 const TOK_NULL = "null"
 
+/*
+Numeric literals that some languages emit but that aren't valid JSON. We look
+for these only once a parse has failed so we can give a clearer error message.
+*/
+var nonStandardNumbers = []string{"NaN", "Infinity", "-Infinity"}
+
 /*
 Represents an error in the input stream that renders it unparsable, i.e. not
 valid JSON.
@@ -36,6 +45,24 @@ func (p *ParseError) Error() string {
 	return p.e
 }
 
+/*
+Returned when a Scanner's deadline passes before it has finished reading its
+input, see Scanner.SetDeadline. It's neither a ParseError nor a ValidationError
+as nothing is known to be wrong with the input itself.
+*/
+var ErrTimeout = fmt.Errorf("Input took too long to read")
+
+/*
+Returned when an error sink asks to stop parsing, see Scanner.SetErrorSink.
+*/
+var ErrStopped = fmt.Errorf("Parsing stopped by the error sink")
+
+/*
+Returned instead of a ValidationError, when the errors have been sent to an
+error sink, see Scanner.SetErrorSink.
+*/
+var ErrInvalid = fmt.Errorf("Input failed validation, see the error sink for details")
+
 type TokenType int
 
 const (
@@ -119,10 +146,353 @@ type Scanner struct {
 	buf    []byte
 	roff   int   // the next byte to process
 	rerr   error // most recent read error
+	fixed  bool  // buf is the caller's input, never re-filled or moved
+	rlen   int   // how much to read at a time, 0 for READ_LEN
+
+	// lines before buf's processed data was discarded, see Position
+	lines     int // the number of newlines
+	lineStart int // the offset just past the last of them
+
+	allowTrailingComma bool
+	allowLeadingPlus   bool
+	maxKeyLen          int // 0 for no limit
+	maxNumDigits       int // 0 for no limit
+	maxObjectKeys      int // 0 for no limit
+	maxDepth           int // 0 for no limit, see DefaultMaxDepth
+	maxElements        int // 0 for no limit
+	depth              int // the number of objects and arrays we're within
+	deadline           time.Time
+	ctx                context.Context // nil for none
+	errSink            func(InvalidData) bool
+	sunk               int // the number of errors sent to errSink
+	captures           []*capture
+}
+
+/*
+The input read since a capture was started, see startCapture. start is where in
+buf the part not yet copied to bytes begins.
+*/
+type capture struct {
+	bytes []byte
+	start int
 }
 
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: r}
+	return &Scanner{r: r, maxDepth: DefaultMaxDepth}
+}
+
+/*
+Creates a Scanner, as NewScanner does, that reads up to bufSize bytes at a time
+instead of READ_LEN. A larger size, e.g. 32KB, means fewer calls to Read for
+large inputs, at the cost of a larger buffer. bufSize must be > 0.
+*/
+func NewScannerSize(r io.Reader, bufSize int) *Scanner {
+	if bufSize <= 0 {
+		panic(fmt.Errorf("Buffer size must be > 0"))
+	}
+	return &Scanner{r: r, rlen: bufSize, maxDepth: DefaultMaxDepth}
+}
+
+/*
+Resets the Scanner to read from r, as though it had just been created by
+NewScanner, but keeping its options (e.g. AllowTrailingComma) and, where
+possible, its buffer. Any deadline is cleared.
+*/
+func (s *Scanner) Reset(r io.Reader) {
+	if s.fixed {
+		// the buffer was never ours
+		s.buf = nil
+	}
+	s.r = r
+	s.rcount = 0
+	s.lines = 0
+	s.lineStart = 0
+	s.buf = s.buf[:0]
+	s.roff = 0
+	s.rerr = nil
+	s.fixed = false
+	s.deadline = time.Time{}
+	s.ctx = nil
+	s.sunk = 0
+	s.depth = 0
+	s.captures = nil
+}
+
+/*
+Creates a Scanner that reads directly from b instead of copying it into an
+internal buffer.
+
+All []byte values returned by the Read* methods will alias b, so b must not be
+modified whilst the Scanner, or anything that was parsed from it using an
+aliasing parser (e.g. RawBytesNoCopy), is still in use.
+*/
+func NewBytesScanner(b []byte) *Scanner {
+	return &Scanner{buf: b, rerr: io.EOF, fixed: true, maxDepth: DefaultMaxDepth}
+}
+
+/*
+Returns the number of bytes of input consumed so far, e.g. to find where one of
+several concatenated values ends. Input that has been read from the reader but
+not yet processed isn't counted.
+*/
+func (s *Scanner) BytesRead() int {
+	return s.rcount
+}
+
+/*
+Returns the position of the read cursor in the input, as a line and column,
+both starting at 1, and a byte offset, starting at 0. Columns count bytes, not
+characters.
+*/
+func (s *Scanner) Position() (line, col, offset int) {
+	offset = s.rcount
+	line, start := s.lines, s.lineStart
+
+	// and any lines in what's been processed but is still in buf
+	done := s.buf[:s.roff]
+	if i := bytes.LastIndexByte(done, '\n'); i >= 0 {
+		line += bytes.Count(done, newline)
+		start = offset - s.roff + i + 1
+	}
+	return line + 1, offset - start + 1, offset
+}
+
+var newline = []byte{'\n'}
+
+/*
+Describes the read cursor's Position for error messages.
+*/
+func (s *Scanner) at() string {
+	line, col, offset := s.Position()
+	return fmt.Sprintf("line %d, column %d (byte %d)", line, col, offset)
+}
+
+/*
+Does this Scanner read directly from a caller provided buffer, i.e. was it
+created with NewBytesScanner.
+
+If so, the []byte values returned by the Read* methods remain valid after
+subsequent reads.
+*/
+func (s *Scanner) IsFixed() bool {
+	return s.fixed
+}
+
+/*
+Sets whether objects and arrays may have a ',' after their last item, e.g.
+[1,2,] or {"a":1,}, which isn't valid JSON but is commonly written by hand.
+
+The default is false, i.e. strict. This applies to the scanner's own SkipValue
+and to all the built-in parsers.
+*/
+func (s *Scanner) AllowTrailingComma(allow bool) {
+	s.allowTrailingComma = allow
+}
+
+/*
+Returns true if the scanner allows trailing commas, see AllowTrailingComma.
+*/
+func (s *Scanner) TrailingCommaAllowed() bool {
+	return s.allowTrailingComma
+}
+
+/*
+Sets whether numbers may start with a '+', e.g. +5, which isn't valid JSON but
+some producers write anyway. The '+' is dropped, so the token read is just the
+number, e.g. 5, and parses as normal.
+
+The default is false, i.e. strict.
+*/
+func (s *Scanner) AllowLeadingPlus(allow bool) {
+	s.allowLeadingPlus = allow
+}
+
+/*
+Limits object keys to at most n bytes, as they appear in the input, i.e. before
+any escape sequences are decoded. Longer keys cause a ParseError as soon as the
+limit is passed, so they're never fully read into memory.
+
+The default, 0, is no limit. Only keys read via ReadKey are checked, which
+includes those read by SkipValue and all the built-in parsers.
+*/
+func (s *Scanner) MaxKeyLength(n int) {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum key length must be >= 0"))
+	}
+	s.maxKeyLen = n
+}
+
+/*
+Limits numbers to at most n digits in total, counting those of the fraction and
+exponent too, e.g. -12.5e3 has 4. Longer numbers cause a ParseError as soon as
+the limit is passed, before they're converted to anything, which guards against
+absurdly long literals whatever the destination type.
+
+The default, 0, is no limit. This applies to all numbers read, including those
+skipped by SkipValue.
+*/
+func (s *Scanner) MaxNumberDigits(n int) {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum number of digits must be >= 0"))
+	}
+	s.maxNumDigits = n
+}
+
+/*
+Limits the number of keys an object parsed into a map can have, e.g. by Map,
+Pairs or a Struct with DottedKeys, to n. Objects with more cause a ParseError as soon as the limit is
+passed, so a hostile input can't grow a map without bound.
+
+The default, 0, is no limit. Objects parsed into structs, or skipped, aren't
+limited as nothing is kept for their unknown keys.
+*/
+func (s *Scanner) MaxObjectKeys(n int) {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum number of object keys must be >= 0"))
+	}
+	s.maxObjectKeys = n
+}
+
+/*
+Limits how deeply objects and arrays can be nested to n. Input nested any
+deeper causes a ParseError as soon as the limit is passed.
+
+The limit covers values that are skipped, e.g. a Struct's unknown props, as
+well as those that are parsed, so a hostile input can't use a deeply nested
+unknown value to exhaust the stack, however shallow the schema is.
+
+The default is DefaultMaxDepth. 0 is no limit, which is only safe for trusted
+input.
+*/
+func (s *Scanner) MaxDepth(n int) {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum depth must be >= 0"))
+	}
+	s.maxDepth = n
+}
+
+/*
+How deeply objects and arrays can be nested unless MaxDepth says otherwise. It's
+far deeper than any sensible input, but keeps a hostile one from exhausting the
+stack.
+*/
+const DefaultMaxDepth = 10000
+
+/*
+Called just after reading an object or array's opening token, checks the value
+isn't nested too deeply. Each successful call must be paired with a call to
+leave once the value has been read.
+*/
+func (s *Scanner) enter() error {
+	if s.maxDepth > 0 && s.depth >= s.maxDepth {
+		return NewParseError(ERROR_TOO_DEEP, s.maxDepth, s.rcount-1)
+	}
+	s.depth++
+	return nil
+}
+
+func (s *Scanner) leave() {
+	s.depth--
+}
+
+/*
+Limits the number of elements a single array, or properties a single object,
+can have to n, whether it's parsed or skipped. Containers with more cause a
+ParseError as soon as the limit is passed, so a hostile input can't make us
+spend unbounded time on one flat but enormous array or object.
+
+The limit is per container, and separate from MaxDepth. MaxObjectKeys, which
+only applies to objects parsed into maps, can be set lower. The default, 0, is
+no limit.
+*/
+func (s *Scanner) MaxElements(n int) {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum number of elements must be >= 0"))
+	}
+	s.maxElements = n
+}
+
+/*
+Checks an array or object isn't too big before its element n, counting from 0,
+is read. read is how many of the element's bytes have been read already, e.g.
+an object's key.
+*/
+func (s *Scanner) element(n, read int) error {
+	if s.maxElements > 0 && n >= s.maxElements {
+		if read == 0 {
+			// skip any space, so we report where the element starts
+			s.PeekToken()
+		}
+		return NewParseError(ERROR_TOO_MANY_ELEMENTS, s.maxElements, s.rcount-read)
+	}
+	return nil
+}
+
+/*
+Sends validation errors to fn as they're found, rather than collecting them
+all, e.g. to log them as they happen when checking huge documents. If fn
+returns false, parsing stops straight away with ErrStopped. The default, nil,
+is to collect them.
+
+Errors are sent once their value has been read. Parsing a value that fails
+validation without stopping returns ErrInvalid, rather than a ValidationError.
+*/
+func (s *Scanner) SetErrorSink(fn func(InvalidData) bool) {
+	s.errSink = fn
+}
+
+/*
+Adds the errors from parsing a value, more, to errs, or, if there's an error
+sink, sends them there instead. Returns ErrStopped if the sink asks to stop.
+*/
+func (s *Scanner) addErrors(errs, more ValidationError) (ValidationError, error) {
+	if s.errSink == nil {
+		return errs.AddMany(more), nil
+	}
+
+	for _, e := range more {
+		s.sunk++
+		if !s.errSink(e) {
+			return errs, ErrStopped
+		}
+	}
+	return errs, nil
+}
+
+/*
+Stops the Scanner reading any more of its input after t, returning ErrTimeout
+instead, to put a bound on how long a slow client can hold up parsing. The zero
+time, the default, means no deadline.
+
+The deadline is checked each time the Scanner needs more data, so it doesn't
+interrupt a Read that blocks. Use the reader's own deadline (e.g.
+net.Conn.SetReadDeadline) for that.
+*/
+func (s *Scanner) SetDeadline(t time.Time) {
+	s.deadline = t
+}
+
+/*
+Stops the Scanner reading any more of its input once ctx is done, returning
+ctx.Err() instead, e.g. to stop parsing a request body when the request is
+cancelled. Like the deadline, ctx is checked each time the Scanner needs more
+data, so it doesn't interrupt a Read that blocks.
+*/
+func (s *Scanner) SetContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+/*
+Parses the next value in the input into v using schema, reporting errors with
+paths relative to "/", exactly as ValidatingParser.Parse does.
+
+Along with PeekToken, this allows code outside this package to choose which
+schema to apply to each value, e.g. based on its type. Unlike Parser, schema is
+not prepared here, so if it needs to be it must first be passed to
+PrepareSchema with the type of v.
+*/
+func (s *Scanner) ParseWith(schema SchemaType, v interface{}) error {
+	return parseRoot(schema, s, v)
 }
 
 /*
@@ -138,6 +508,26 @@ func (s *Scanner) SkipValue() error {
 	return s._skipValue(tok)
 }
 
+/*
+Reads a single value, returning its JSON exactly as it is in the input,
+including any whitespace within it, but not around it. The returned slice is a
+copy, so it remains valid after later reads.
+*/
+func (s *Scanner) ReadRawValue() ([]byte, error) {
+	// capture from the first byte of the value, not any space before it
+	if tok, err := s.PeekToken(); tok == TokenError {
+		return nil, err
+	}
+
+	c := s.startCapture()
+	err := s.SkipValue()
+	raw := s.endCapture(c)
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
 func (s *Scanner) _skipValue(tok TokenType) error {
 	switch tok {
 	default:
@@ -153,14 +543,24 @@ func (s *Scanner) _skipValue(tok TokenType) error {
 }
 
 func (s *Scanner) skipObject() error {
-	for {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+
+	for n := 0; ; n++ {
 		// read the key, or '}'
-		if tok, _, err := s.ReadToken(); err != nil {
+		if tok, keyb, err := s.ReadKey(); err != nil {
 			return err
 		} else if tok == TokenObjectEnd {
+			if n > 0 && !s.allowTrailingComma {
+				return NewParseError(ERROR_TRAILING_COMMA_OBJ)
+			}
 			break
 		} else if tok != TokenString {
 			return NewParseError("Expected string or '}', not " + tok.String())
+		} else if err := s.element(n, len(keyb)); err != nil {
+			return err
 		}
 
 		// now read the ':'
@@ -190,11 +590,21 @@ func (s *Scanner) skipObject() error {
 }
 
 func (s *Scanner) skipArray() error {
-	for {
-		if tok, _, err := s.ReadToken(); err != nil {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+
+	for n := 0; ; n++ {
+		if tok, buf, err := s.ReadToken(); err != nil {
 			return err
 		} else if tok == TokenArrayEnd {
+			if n > 0 && !s.allowTrailingComma {
+				return NewParseError(ERROR_TRAILING_COMMA_ARR)
+			}
 			break
+		} else if err := s.element(n, len(buf)); err != nil {
+			return err
 		} else if err := s._skipValue(tok); err != nil {
 			return err
 		}
@@ -215,6 +625,224 @@ func (s *Scanner) skipArray() error {
 	return nil
 }
 
+/*
+Creates a Scanner for b, a value that has been read from s, with the same
+options, so that it can be parsed as though it came straight from s, e.g. once
+it has been rearranged. Errors sent to its error sink are counted as s's.
+*/
+func (s *Scanner) subScanner(b []byte) *Scanner {
+	ns := NewBytesScanner(b)
+	ns.allowTrailingComma = s.allowTrailingComma
+	ns.allowLeadingPlus = s.allowLeadingPlus
+	ns.maxKeyLen = s.maxKeyLen
+	ns.maxNumDigits = s.maxNumDigits
+	ns.maxObjectKeys = s.maxObjectKeys
+	ns.maxDepth = s.maxDepth
+	ns.maxElements = s.maxElements
+	ns.depth = s.depth
+	if s.errSink != nil {
+		ns.errSink = func(e InvalidData) bool {
+			s.sunk++
+			return s.errSink(e)
+		}
+	}
+	return ns
+}
+
+/*
+Starts keeping a copy of everything read from here on, exactly as it is, until
+endCapture is called with the returned capture. Captures can be nested.
+*/
+func (s *Scanner) startCapture() *capture {
+	c := &capture{start: s.roff}
+	s.captures = append(s.captures, c)
+	return c
+}
+
+/*
+Stops the capture c, returning everything read since it was started.
+*/
+func (s *Scanner) endCapture(c *capture) []byte {
+	c.bytes = append(c.bytes, s.buf[c.start:s.roff]...)
+	for i := len(s.captures) - 1; i >= 0; i-- {
+		if s.captures[i] == c {
+			s.captures = append(s.captures[:i], s.captures[i+1:]...)
+			break
+		}
+	}
+	return c.bytes
+}
+
+/*
+Reads a single value, appending it to dst as is, but without any whitespace.
+*/
+func (s *Scanner) appendValue(dst []byte) ([]byte, error) {
+	tok, buf, err := s.ReadToken()
+	if tok == TokenError {
+		return dst, err
+	}
+	dst = append(dst, buf...)
+
+	switch tok {
+	default:
+		return dst, NewParseError("Expected JSON value, e.g. string, bool, etc.")
+	case TokenString, TokenNumber, TokenTrue, TokenFalse, TokenNull:
+		return dst, nil
+	case TokenObjectBegin:
+		return s.appendObject(dst)
+	case TokenArrayBegin:
+		return s.appendArray(dst)
+	}
+}
+
+func (s *Scanner) appendObject(dst []byte) ([]byte, error) {
+	if err := s.enter(); err != nil {
+		return dst, err
+	}
+	defer s.leave()
+
+	for n := 0; ; n++ {
+		// read the key, or '}'
+		tok, buf, err := s.ReadKey()
+		if tok == TokenError {
+			return dst, err
+		} else if tok == TokenObjectEnd {
+			if n == 0 {
+				return append(dst, '}'), nil
+			} else if !s.allowTrailingComma {
+				return dst, NewParseError(ERROR_TRAILING_COMMA_OBJ)
+			}
+			// replace the trailing ','
+			dst[len(dst)-1] = '}'
+			return dst, nil
+		} else if tok != TokenString {
+			return dst, NewParseError("Expected string or '}', not %v", tok)
+		} else if err := s.element(n, len(buf)); err != nil {
+			return dst, err
+		}
+		dst = append(dst, buf...)
+
+		// now read the ':'
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return dst, err
+		} else if tok != TokenPropSep {
+			return dst, NewParseError("Expected ':' not %v", tok)
+		}
+		dst = append(dst, ':')
+
+		if dst, err = s.appendValue(dst); err != nil {
+			return dst, err
+		}
+
+		// we want a , or a }
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return dst, err
+		} else if tok == TokenObjectEnd {
+			return append(dst, '}'), nil
+		} else if tok != TokenItemSep {
+			return dst, NewParseError("Expected ',' or '}', not %v", tok)
+		}
+		dst = append(dst, ',')
+	}
+}
+
+func (s *Scanner) appendArray(dst []byte) ([]byte, error) {
+	if err := s.enter(); err != nil {
+		return dst, err
+	}
+	defer s.leave()
+
+	for n := 0; ; n++ {
+		if tok, err := s.PeekToken(); tok == TokenError {
+			return dst, err
+		} else if tok == TokenArrayEnd {
+			s.ReadToken()
+			if n == 0 {
+				return append(dst, ']'), nil
+			} else if !s.allowTrailingComma {
+				return dst, NewParseError(ERROR_TRAILING_COMMA_ARR)
+			}
+			// replace the trailing ','
+			dst[len(dst)-1] = ']'
+			return dst, nil
+		}
+
+		if err := s.element(n, 0); err != nil {
+			return dst, err
+		}
+
+		var err error
+		if dst, err = s.appendValue(dst); err != nil {
+			return dst, err
+		}
+
+		// we want a , or a ]
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return dst, err
+		} else if tok == TokenArrayEnd {
+			return append(dst, ']'), nil
+		} else if tok != TokenItemSep {
+			return dst, NewParseError("Expected ',' or ']', not %v", tok)
+		}
+		dst = append(dst, ',')
+	}
+}
+
+/*
+Skips forward through an object's props, leaving the read cursor at the value
+of the one named name, just past its ':', and returning true. If the object
+ends first, its '}' is read and false returned.
+
+This must be called just after reading the object's '{', or the ',' after a
+value, e.g. to look for a second key once the first key's value has been read.
+Keys are matched exactly, after unescaping.
+*/
+func (s *Scanner) SkipToKey(name string) (bool, error) {
+	for first := true; ; first = false {
+		// read the key, or '}'
+		tok, keyb, err := s.ReadKey()
+		if tok == TokenError {
+			return false, err
+		} else if tok == TokenObjectEnd {
+			if !first && !s.allowTrailingComma {
+				return false, NewParseError(ERROR_TRAILING_COMMA_OBJ)
+			}
+			return false, nil
+		} else if tok != TokenString {
+			return false, NewParseError("Expected object property name or '}' not %v", tok)
+		}
+
+		// only unescape the key if we have to, ReadToken will invalidate keyb
+		found := string(keyb[1:len(keyb)-1]) == name
+		if !found && bytes.IndexByte(keyb, '\\') >= 0 {
+			key, ok := Unquote(keyb)
+			found = ok && key == name
+		}
+
+		// read the ':'
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return false, err
+		} else if tok != TokenPropSep {
+			return false, NewParseError("Expected ':' not %v", tok)
+		}
+
+		if found {
+			return true, nil
+		} else if err := s.SkipValue(); err != nil {
+			return false, err
+		}
+
+		// we want a , or a }
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return false, err
+		} else if tok == TokenObjectEnd {
+			return false, nil
+		} else if tok != TokenItemSep {
+			return false, NewParseError("Expected ',' or '}' not %v", tok)
+		}
+	}
+}
+
 /*
 Reads forward to the next Token, but only returns its type, leaves the read
 cursor pointed at its first byte, unlike ReadToken which leaves the read cursor
@@ -257,6 +885,9 @@ func (s *Scanner) PeekToken() (TokenType, error) {
 	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 		tok = TokenNumber
 	default:
+		if err := s.nonStandardNumber(); err != nil {
+			return TokenError, err
+		}
 		return TokenError, NewParseError("Invaid JSON")
 	}
 
@@ -277,6 +908,22 @@ The returned error can be of 2 different types:
  2. ParseError: We have the data, but it was malformed, parsing cannot continue.
 */
 func (s *Scanner) ReadToken() (TokenType, []byte, error) {
+	return s.readToken(0)
+}
+
+/*
+Same as ReadToken, but for reading an object's key (or its end), so applies
+the limit set by MaxKeyLength to string tokens.
+*/
+func (s *Scanner) ReadKey() (TokenType, []byte, error) {
+	return s.readToken(s.maxKeyLen)
+}
+
+/*
+Reads in one JSON token. If maxKey > 0, string tokens longer than maxKey bytes,
+excluding the quotes, are reported as over-long keys.
+*/
+func (s *Scanner) readToken(maxKey int) (TokenType, []byte, error) {
 	// move to first non-space char (s.buf[s.roff] != space)
 	var n int
 	n, s.rerr = s.bytesUntilPred(0, notSpace) // could discardUntil to eliminate pointless allocations, but not the common case.
@@ -313,6 +960,15 @@ func (s *Scanner) ReadToken() (TokenType, []byte, error) {
 		return tok, buf, nil
 	}
 
+	// drop a leading '+' from a number, if allowed
+	if first == '+' && s.allowLeadingPlus {
+		if err := s.atLeast(2); err == nil && s.buf[s.roff+1] >= '0' && s.buf[s.roff+1] <= '9' {
+			s.roff += 1
+			s.rcount += 1
+			first = s.buf[s.roff]
+		}
+	}
+
 	// now deal with string tokens (true, false, nill)
 	var lookFor string
 	switch first {
@@ -347,11 +1003,18 @@ func (s *Scanner) ReadToken() (TokenType, []byte, error) {
 		// if it's a ", we've found the end!
 		escapePos := -100
 		offset := 0
+		max := -1
+		if maxKey > 0 {
+			// the closing '"' can be at most here
+			max = maxKey + 1
+		}
 		for {
 			// start reading from last stop character + 1
 			offset += 1
-			offset, err := s.bytesUntilPred(offset, func(c byte) bool { return c == '\\' || c == '"' })
-			if err != nil {
+			offset, err := s.bytesUntilPredMax(offset, max, func(c byte) bool { return c == '\\' || c == '"' })
+			if err == errPredMax {
+				return TokenError, s.buf[s.roff:], NewParseError(ERROR_KEY_TOO_LONG, maxKey, s.at())
+			} else if err != nil {
 				break
 			}
 
@@ -381,12 +1044,30 @@ func (s *Scanner) ReadToken() (TokenType, []byte, error) {
 			state = numState1
 		}
 
-		var perr error
+		var perr, rerr error
 		var offset int
-		for offset = 1; s.atLeast(offset+1) == nil; offset += 1 {
+		digits := 0
+		if first != '-' {
+			digits = 1
+		}
+		for offset = 1; ; offset += 1 {
+			if rerr = s.atLeast(offset + 1); rerr != nil {
+				break
+			}
+
+			c := s.buf[s.roff+offset]
+			if c >= '0' && c <= '9' {
+				if digits++; s.maxNumDigits > 0 && digits > s.maxNumDigits {
+					return TokenError, s.buf[s.roff:], NewParseError(ERROR_NUMBER_TOO_LONG, s.maxNumDigits, s.at())
+				}
+			}
+
 			// push it through the machine
-			state, perr = state(s.buf[s.roff+offset])
+			state, perr = state(c)
 			if perr != nil {
+				if err := s.nonStandardNumber(); err != nil {
+					perr = err
+				}
 				return TokenError, s.buf[s.roff:], perr
 			} else if state == nil {
 				// finished
@@ -395,9 +1076,14 @@ func (s *Scanner) ReadToken() (TokenType, []byte, error) {
 		}
 
 		// we might be at the end of our input, so hand a fake ' ' to finish off
-		// an incomplete parse
+		// an incomplete parse, unless it was cut short by some other error
 		if state != nil {
-			state, _ = state(0x20)
+			if rerr != io.EOF {
+				return TokenError, s.buf[s.roff:], rerr
+			} else if state, perr = state(0x20); perr != nil {
+				// it's truncated, e.g. "1." or "-", the same as an unclosed string
+				return TokenError, s.buf[s.roff:], io.EOF
+			}
 		}
 		if state == nil {
 			buf := s.buf[s.roff : s.roff+offset]
@@ -406,7 +1092,13 @@ func (s *Scanner) ReadToken() (TokenType, []byte, error) {
 			return TokenNumber, buf, nil
 		}
 	} else {
-		return TokenError, s.buf[s.roff:], NewParseError("Expected valid JSON")
+		if err := s.nonStandardNumber(); err != nil {
+			return TokenError, s.buf[s.roff:], err
+		}
+		// snippet may read more input, so it must be called first
+		snippet := s.snippet()
+		err := NewParseError(ERROR_INVALID_TOKEN, s.at(), snippet)
+		return TokenError, s.buf[s.roff:], err
 	}
 
 	if s.rerr != nil {
@@ -416,6 +1108,48 @@ func (s *Scanner) ReadToken() (TokenType, []byte, error) {
 	}
 }
 
+/*
+Checks if the input at the read cursor is one of the nonStandardNumbers and if
+so, returns a ParseError naming it and its position in the input.
+*/
+func (s *Scanner) nonStandardNumber() error {
+	for _, lit := range nonStandardNumbers {
+		if err := s.atLeast(len(lit)); err != nil {
+			continue
+		}
+		if string(s.buf[s.roff:s.roff+len(lit)]) == lit {
+			return NewParseError(ERROR_NON_STANDARD_NUMBER, lit, s.at())
+		}
+	}
+	return nil
+}
+
+/*
+Reports whether b is a single, valid JSON value, optionally surrounded by
+whitespace.
+*/
+func Valid(b []byte) bool {
+	return validJSON(b) == nil
+}
+
+/*
+Checks b is a single, valid JSON value, returning an error describing the
+first problem found and its position if not.
+*/
+func validJSON(b []byte) error {
+	s := NewBytesScanner(b)
+	if err := s.SkipValue(); err == io.EOF {
+		return fmt.Errorf("Unexpected end of input, at byte %d", s.rcount)
+	} else if err != nil {
+		return fmt.Errorf("%v, at byte %d", err, s.rcount)
+	}
+
+	if tok, err := s.PeekToken(); tok != TokenError || err != io.EOF {
+		return fmt.Errorf("Unexpected data after value, at byte %d", s.rcount)
+	}
+	return nil
+}
+
 /*
 Will read in data in until there is at least count bytes in the buffer.
 */
@@ -428,12 +1162,44 @@ func (s *Scanner) atLeast(count int) error {
 	return nil
 }
 
+/*
+The most input shown in an error message by snippet.
+*/
+const snippetLen = 16
+
+/*
+Returns the input from the read cursor, up to snippetLen bytes of it, quoted
+with any control characters or invalid UTF-8 escaped, so that it's safe to
+include in error messages and logs.
+*/
+func (s *Scanner) snippet() string {
+	// one more, to know if there's more to show, errors are ignored as it's
+	// only for the message
+	s.atLeast(snippetLen + 1)
+
+	b := s.buf[s.roff:]
+	if len(b) > snippetLen {
+		return strconv.Quote(string(b[:snippetLen])) + "..."
+	}
+	return strconv.Quote(string(b))
+}
+
 /*
 Reads from s.roff+offset until it finds a byte where the pred returns true.
 Returns the offset of that byte, relative to s.roff.
 */
 func (s *Scanner) bytesUntilPred(offset int, p bytePred) (int, error) {
-	for i := 0; i < 1024; i += 1 {
+	return s.bytesUntilPredMax(offset, -1, p)
+}
+
+var errPredMax = NewParseError("Reached max offset and no result")
+
+/*
+Same as bytesUntilPred, but gives up and returns errPredMax if no byte has been
+found by the time offset reaches max. A max < 0 means there's no limit.
+*/
+func (s *Scanner) bytesUntilPredMax(offset, max int, p bytePred) (int, error) {
+	for {
 		// make sure there's at least 1-byte to read
 		for len(s.buf) <= s.roff+offset {
 			if err := s.fillBuffer(); err != nil {
@@ -445,42 +1211,88 @@ func (s *Scanner) bytesUntilPred(offset int, p bytePred) (int, error) {
 		for _, c := range s.buf[s.roff+offset:] {
 			if p(c) {
 				return offset, nil
+			} else if max >= 0 && offset >= max {
+				return offset, errPredMax
 			} else {
 				offset += 1
 			}
 		}
 	}
+}
+
+/*
+The number of times in a row a Read can return nothing before fillBuffer gives
+up with io.ErrNoProgress.
+*/
+const maxEmptyReads = 100
 
-	return offset, NewParseError("1024 iterations and no result")
+/*
+How much to read at a time, see NewScannerSize.
+*/
+func (s *Scanner) readLen() int {
+	if s.rlen > 0 {
+		return s.rlen
+	}
+	return READ_LEN
 }
 
 /*
-Reads in up-to another READ_LEN count bytes into our buffer
+Reads in up-to another readLen count bytes into our buffer
 */
 func (s *Scanner) fillBuffer() error {
-	if s.rerr != nil {
+	if s.fixed {
+		// there is no more, and the buffer isn't ours to move around
+		return io.EOF
+	} else if s.rerr != nil {
+		return s.rerr
+	} else if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		s.rerr = ErrTimeout
+		return s.rerr
+	} else if s.ctx != nil && s.ctx.Err() != nil {
+		s.rerr = s.ctx.Err()
 		return s.rerr
 	}
 
 	// ensure space for the read
-	if cap(s.buf)-len(s.buf) < READ_LEN {
+	readLen := s.readLen()
+	if cap(s.buf)-len(s.buf) < readLen {
+		// processed data is about to go, so keep any that's being captured
+		for _, c := range s.captures {
+			c.bytes = append(c.bytes, s.buf[c.start:s.roff]...)
+			c.start = 0
+		}
+
+		// and count the lines in it, for Position
+		done := s.buf[:s.roff]
+		if i := bytes.LastIndexByte(done, '\n'); i >= 0 {
+			s.lines += bytes.Count(done, newline)
+			s.lineStart = s.rcount - s.roff + i + 1
+		}
+
 		used := len(s.buf) - s.roff
-		if cap(s.buf)-used >= READ_LEN {
+		if cap(s.buf)-used >= readLen {
 			// buffer can fit if we eliminate already processed data
 			rest := copy(s.buf, s.buf[s.roff:])
 			s.buf = s.buf[0:rest]
 		} else {
 			// need a bigger buffer
-			newBuf := make([]byte, used, 2*cap(s.buf)+READ_LEN)
+			newBuf := make([]byte, used, 2*cap(s.buf)+readLen)
 			copy(newBuf, s.buf[s.roff:])
 			s.buf = newBuf
 		}
 		s.roff = 0
 	}
 
-	// now read it in and store any potential error for post-parse checking
+	// now read it in and store any potential error for post-parse checking,
+	// giving up on readers that never return anything, as bufio does
 	var n int
-	n, s.rerr = s.r.Read(s.buf[len(s.buf):cap(s.buf)])
+	for i := 0; n == 0 && s.rerr == nil; i++ {
+		if i == maxEmptyReads {
+			s.rerr = io.ErrNoProgress
+			break
+		}
+		n, s.rerr = s.r.Read(s.buf[len(s.buf):cap(s.buf)])
+	}
 	s.buf = s.buf[0 : len(s.buf)+n]
 
 	// normalise to only return error with no data
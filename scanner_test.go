@@ -2,9 +2,12 @@ package jsonv
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func Test_scannerTokens(t *testing.T) {
@@ -63,6 +66,165 @@ func Test_scannerTokens(t *testing.T) {
 	}
 }
 
+func Test_scannerNumberAtEOF(t *testing.T) {
+	// with nothing after them, numbers are finished off by the end of input
+	cases := []string{"0", "-0", "5", "-5", "123", "-123", "12.5", "-0.5", "1e5", "1.5E-3"}
+	truncated := []string{"-", "1.", "-0.", "1e", "1e+", "12.5E-"}
+
+	scanners := map[string]func(string) *Scanner{
+		"reader": func(json string) *Scanner { return NewScanner(bytes.NewBufferString(json)) },
+		"one":    func(json string) *Scanner { return NewScanner(&oneByteReader{bytes.NewReader([]byte(json))}) },
+		"bytes":  func(json string) *Scanner { return NewBytesScanner([]byte(json)) },
+	}
+
+	for name, mk := range scanners {
+		for _, json := range cases {
+			s := mk(json)
+			if tok, b, err := s.ReadToken(); err != nil || tok != TokenNumber || string(b) != json {
+				t.Errorf("%s %q: Got %v %q %v, want the whole number", name, json, tok, b, err)
+			} else if tok, _, err := s.ReadToken(); tok != TokenError || err != io.EOF {
+				t.Errorf("%s %q: Got %v %v after the number, want EOF", name, json, tok, err)
+			}
+		}
+
+		// but aren't if they're incomplete
+		for _, json := range truncated {
+			s := mk(json)
+			if tok, b, err := s.ReadToken(); tok != TokenError || err != io.EOF {
+				t.Errorf("%s %q: Got %v %q %v, want EOF", name, json, tok, b, err)
+			}
+		}
+	}
+
+	for _, json := range truncated {
+		if Valid([]byte(json)) {
+			t.Errorf("%q: Got valid, want invalid", json)
+		}
+	}
+}
+
+func Test_scannerOneByteReads(t *testing.T) {
+	docs := []string{
+		`{"a": [1, -2.5e10, true, false, null, "x"], "b": {}}`,
+		`"` + strings.Repeat(`long string \"with\" escapes \u00e9 `, 200) + `"`,
+		`[` + strings.Repeat("9", 5000) + `, -` + strings.Repeat("1", 3000) + `.` + strings.Repeat("5", 3000) + `e-10]`,
+		strings.Repeat(" \n\t", 2000) + `{"spaced" :` + strings.Repeat(" ", 3000) + `"out"}` + strings.Repeat(" ", 2000),
+		strings.Repeat(`{"k": [`, 200) + `0` + strings.Repeat(`]}`, 200),
+		`{"` + strings.Repeat("k", 4000) + `": "` + strings.Repeat("\\", 2000) + `"}`,
+	}
+
+	// every token must be the same as when it's all there at once
+	for i, doc := range docs {
+		whole := NewBytesScanner([]byte(doc))
+		s := NewScanner(&oneByteReader{bytes.NewReader([]byte(doc))})
+		for n := 0; ; n++ {
+			wantTok, wantBuf, wantErr := whole.ReadToken()
+			tok, buf, err := s.ReadToken()
+			if tok != wantTok || string(buf) != string(wantBuf) || err != wantErr {
+				t.Fatalf("Doc %d, token %d: Got %v %.20q %v, want %v %.20q %v", i, n, tok, buf, err, wantTok, wantBuf, wantErr)
+			} else if tok == TokenError {
+				break
+			}
+		}
+
+		s = NewScanner(&oneByteReader{bytes.NewReader([]byte(doc))})
+		if err := s.SkipValue(); err != nil {
+			t.Errorf("Doc %d: Got error %v skipping, want nil", i, err)
+		} else if !Valid([]byte(doc)) {
+			t.Errorf("Doc %d: Got invalid, want valid", i)
+		}
+	}
+
+	// and parsing, with strings and numbers split over many reads
+	type doc struct {
+		Name string
+		Nums []float64
+		Tags map[string]string
+	}
+	name := strings.Repeat("ñame ", 1000)
+	json := `{"Name": "` + name + `", "Nums": [1.5, -0.25, 1e300], "Tags": {"` + strings.Repeat("t", 2000) + `": "v"}}`
+	schema := Struct(Prop("Name", String()), Prop("Nums", Slice(Float())), Prop("Tags", Map(String())))
+	var got doc
+	if err := Parser(&got, schema).Parse(&oneByteReader{bytes.NewReader([]byte(json))}, &got); err != nil {
+		t.Fatal(err)
+	} else if want := (doc{name, []float64{1.5, -0.25, 1e300}, map[string]string{strings.Repeat("t", 2000): "v"}}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Got %.40v, want %.40v", got, want)
+	}
+
+	// truncated input is still an error, however it's read
+	for _, doc := range []string{`{"a": "unfinished`, `[1, 2`, `{"a": tru`, `12.`} {
+		s := NewScanner(&oneByteReader{bytes.NewReader([]byte(doc))})
+		if err := s.SkipValue(); err != io.EOF {
+			t.Errorf("%q: Got %v, want EOF", doc, err)
+		}
+	}
+}
+
+func Test_scannerMultiMegabyteTokens(t *testing.T) {
+	type doc struct {
+		Name string
+	}
+	name := strings.Repeat("0123456789abcdef", 4<<20/16)
+	json := strings.Repeat(" ", 1<<20) + `{"Name":` + strings.Repeat("\n", 1<<20) + `"` + name + `"}`
+
+	readers := []io.Reader{
+		bytes.NewReader([]byte(json)),
+		iotest.HalfReader(bytes.NewReader([]byte(json))),
+	}
+	for i, r := range readers {
+		var got doc
+		if err := Parser(&got, Struct(Prop("Name", String()))).Parse(r, &got); err != nil {
+			t.Fatalf("Reader %d: %v", i, err)
+		} else if got.Name != name {
+			t.Fatalf("Reader %d: Got a %d byte name, want %d bytes", i, len(got.Name), len(name))
+		}
+	}
+}
+
+func Test_scannerBufferSize(t *testing.T) {
+	json := `[` + strings.Repeat(`"abcdefghijklmnopqrstuvwxyz", `, 10000) + `""]`
+
+	reads := func(s *Scanner, r *countingReader) int {
+		if err := s.SkipValue(); err != nil {
+			t.Fatalf("Got error %v, want nil", err)
+		} else if s.BytesRead() != len(json) {
+			t.Fatalf("Got %d bytes read, want %d", s.BytesRead(), len(json))
+		}
+		return r.reads
+	}
+
+	small := &countingReader{r: strings.NewReader(json)}
+	large := &countingReader{r: strings.NewReader(json)}
+	if got, want := reads(NewScannerSize(large, 64<<10), large), reads(NewScanner(small), small)/64; got > want {
+		t.Fatalf("Got %d reads with a 64KB buffer, want no more than %d", got, want)
+	}
+}
+
+// counts the calls to Read
+type countingReader struct {
+	r     io.Reader
+	reads int
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	r.reads++
+	return r.r.Read(b)
+}
+
+// always returns 0, nil
+type noProgressReader struct{}
+
+func (r noProgressReader) Read(p []byte) (int, error) {
+	return 0, nil
+}
+
+func Test_scannerNoProgress(t *testing.T) {
+	s := NewScanner(noProgressReader{})
+	if tok, _, err := s.ReadToken(); tok != TokenError || err != io.ErrNoProgress {
+		t.Fatalf("Got %v %v, want io.ErrNoProgress", tok, err)
+	}
+}
+
 // test skipValue
 // Used by Object when it needs to jump an unneeded property.
 //
@@ -182,3 +344,300 @@ func Test_scannerLargeSource(t *testing.T) {
 		t.Fatalf("Got %v, err %v. Want %v", tok, err, TokenArrayEnd)
 	}
 }
+
+func Test_scannerNonStandardNumbers(t *testing.T) {
+	cases := []struct {
+		json string
+		want string
+	}{
+		{"NaN", "NaN is not valid JSON, at line 1, column 1 (byte 0)"},
+		{"Infinity", "Infinity is not valid JSON, at line 1, column 1 (byte 0)"},
+		{"-Infinity", "-Infinity is not valid JSON, at line 1, column 1 (byte 0)"},
+		{"  NaN,", "NaN is not valid JSON, at line 1, column 3 (byte 2)"},
+		{" -Infinity]", "-Infinity is not valid JSON, at line 1, column 2 (byte 1)"},
+	}
+
+	for i, c := range cases {
+		s := NewScanner(bytes.NewBufferString(c.json))
+
+		tok, _, err := s.ReadToken()
+		if tok != TokenError {
+			t.Errorf("Case %d: Got token %v, want %v", i, tok, TokenError)
+		} else if perr, ok := err.(*ParseError); !ok {
+			t.Errorf("Case %d: Got error %v, want a ParseError", i, err)
+		} else if perr.Error() != c.want {
+			t.Errorf("Case %d: Got \"%v\", want \"%v\"", i, perr, c.want)
+		}
+	}
+}
+
+func Test_scannerInvalidSnippet(t *testing.T) {
+	cases := []struct {
+		json string
+		want string
+	}{
+		{"x", `Expected valid JSON at line 1, column 1 (byte 0), got "x"`},
+		{`  [1, @foo]`, `Expected valid JSON at line 1, column 7 (byte 6), got "@foo]"`},
+		{"<html><body>Bad Gateway</body></html>", `Expected valid JSON at line 1, column 1 (byte 0), got "<html><body>Bad "...`},
+		{"\x00\x01\x1b[31m\xff\xfe", `Expected valid JSON at line 1, column 1 (byte 0), got "\x00\x01\x1b[31m\xff\xfe"`},
+		{"\x00" + strings.Repeat("\xff", 100), `Expected valid JSON at line 1, column 1 (byte 0), got "\x00` + strings.Repeat(`\xff`, 15) + `"...`},
+	}
+
+	for i, c := range cases {
+		// with a reader that hands over a byte at a time, so the snippet must
+		// read ahead for itself
+		s := NewScanner(&oneByteReader{bytes.NewReader([]byte(c.json))})
+
+		err := s.SkipValue()
+		if _, ok := err.(*ParseError); !ok {
+			t.Errorf("Case %d: Got %v, want a ParseError", i, err)
+		} else if err.Error() != c.want {
+			t.Errorf("Case %d: Got %q, want %q", i, err, c.want)
+		}
+	}
+}
+
+func Test_scannerPosition(t *testing.T) {
+	// long enough that processed input is discarded from the buffer along the way
+	json := "[\n" + strings.Repeat("  1,\n", 300) + "  @]"
+	want := `Expected valid JSON at line 302, column 3 (byte 1504), got "@]"`
+
+	scanners := []*Scanner{
+		NewScanner(bytes.NewBufferString(json)),
+		NewScanner(&oneByteReader{bytes.NewReader([]byte(json))}),
+		NewBytesScanner([]byte(json)),
+	}
+	for i, s := range scanners {
+		if err := s.SkipValue(); err == nil || err.Error() != want {
+			t.Errorf("Scanner %d: Got %v, want %q", i, err, want)
+		}
+	}
+
+	s := NewScanner(bytes.NewBufferString("{\n\t\"a\": 1\n}"))
+	if line, col, offset := s.Position(); line != 1 || col != 1 || offset != 0 {
+		t.Errorf("Got %d:%d (%d), want 1:1 (0)", line, col, offset)
+	}
+	s.ReadToken()
+	s.ReadToken()
+	if line, col, offset := s.Position(); line != 2 || col != 5 || offset != 6 {
+		t.Errorf("Got %d:%d (%d), want 2:5 (6)", line, col, offset)
+	}
+}
+
+func Test_bytesScannerEOF(t *testing.T) {
+	// spare capacity, so any attempt to compact the buffer would be visible
+	input := make([]byte, 0, 64)
+	input = append(input, `  [1, "two"]  `...)
+	orig := string(input)
+
+	s := NewBytesScanner(input)
+	if err := s.SkipValue(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if tok, _, err := s.ReadToken(); tok != TokenError || err != io.EOF {
+			t.Fatalf("Got %v, %v, want EOF", tok, err)
+		}
+	}
+
+	if string(input) != orig {
+		t.Fatalf("Input was modified, got %q, want %q", input, orig)
+	}
+}
+
+func Test_scannerMaxKeyLength(t *testing.T) {
+	cases := []struct {
+		json    string
+		isValid bool
+	}{
+		{`{"abcd": 1}`, true},
+		{`{"abcde": 1}`, false},
+		{`{"a\"cd": 1}`, false}, // escapes count as written
+		{`{"ab": "a value that's longer than the key limit"}`, true},
+		{`{"ab": {"abcd": [{"abcdef": 1}]}}`, false},
+	}
+
+	for i, c := range cases {
+		s := NewScanner(bytes.NewBufferString(c.json))
+		s.MaxKeyLength(4)
+
+		err := s.SkipValue()
+		if c.isValid && err != nil {
+			t.Errorf("Case %d: Got error %v, want nil", i, err)
+		} else if !c.isValid {
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("Case %d: Got %v, want a ParseError", i, err)
+			}
+		}
+	}
+
+	// a huge key should be rejected without being read into memory
+	huge := `{"` + strings.Repeat("k", 1<<20) + `": 1}`
+	s := NewScanner(bytes.NewBufferString(huge))
+	s.MaxKeyLength(16)
+	if err := s.SkipValue(); err == nil {
+		t.Fatalf("Got no error, wanted one")
+	} else if want := "Object key is longer than 16 bytes, at line 1, column 2 (byte 1)"; err.Error() != want {
+		t.Fatalf("Got \"%v\", want \"%v\"", err, want)
+	} else if cap(s.buf) > 4*READ_LEN {
+		t.Fatalf("Got buffer of %d bytes, want no more than %d", cap(s.buf), 4*READ_LEN)
+	}
+}
+
+func Test_scannerMaxNumberDigits(t *testing.T) {
+	cases := []struct {
+		json    string
+		isValid bool
+	}{
+		{`123456`, true},
+		{`-123456`, true},
+		{`1234567`, false},
+		{`-1234567`, false},
+		{`1234.56`, true},
+		{`1234.567`, false},
+		{`12.5e123`, true},
+		{`12.5e1234`, false},
+		{`[1, 22, 333333, "12345678"]`, true},
+		{`{"a": [1, 22, 3333333]}`, false},
+	}
+
+	for i, c := range cases {
+		s := NewScanner(bytes.NewBufferString(c.json))
+		s.MaxNumberDigits(6)
+
+		err := s.SkipValue()
+		if c.isValid && err != nil {
+			t.Errorf("Case %d: Got error %v, want nil", i, err)
+		} else if !c.isValid {
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("Case %d: Got %v, want a ParseError", i, err)
+			}
+		}
+	}
+
+	s := NewScanner(bytes.NewBufferString(`[` + strings.Repeat("9", 1<<16) + `]`))
+	s.MaxNumberDigits(6)
+	if err := s.SkipValue(); err == nil {
+		t.Fatalf("Got no error, wanted one")
+	} else if want := "Number has more than 6 digits, at line 1, column 2 (byte 1)"; err.Error() != want {
+		t.Fatalf("Got \"%v\", want \"%v\"", err, want)
+	}
+}
+
+func Test_scannerSkipToKey(t *testing.T) {
+	cases := []struct {
+		json  string
+		found bool
+		want  int64
+	}{
+		{`{"target": 1}`, true, 1},
+		{`{
+			"a": {"x": [1, {"y": "}"}], "target": 0},
+			"b": [[], [{}], "target"],
+			"c\u0074": "not it",
+			"d": null,
+			"t\u0061rget": 7,
+			"e": 8
+		}`, true, 7},
+		{`{"a": {"target": 1}, "b": ["target", 2]}`, false, 0},
+		{`{}`, false, 0},
+	}
+
+	for i, c := range cases {
+		s := NewScanner(bytes.NewBufferString(c.json))
+		if tok, _, err := s.ReadToken(); tok != TokenObjectBegin {
+			t.Fatalf("Case %d: Got %v %v, want '{'", i, tok, err)
+		}
+
+		found, err := s.SkipToKey("target")
+		if err != nil {
+			t.Errorf("Case %d: Unexpected error %v", i, err)
+			continue
+		} else if found != c.found {
+			t.Errorf("Case %d: Got found %v, want %v", i, found, c.found)
+			continue
+		} else if !found {
+			// the whole object should have been read
+			if tok, _, err := s.ReadToken(); err != io.EOF {
+				t.Errorf("Case %d: Got %v %v after the object, want EOF", i, tok, err)
+			}
+			continue
+		}
+
+		// we should be at the value
+		var got int64
+		if err := s.ParseWith(Integer(), &got); err != nil {
+			t.Errorf("Case %d: Unexpected error %v", i, err)
+		} else if got != c.want {
+			t.Errorf("Case %d: Got %v, want %v", i, got, c.want)
+		}
+	}
+
+	bad := []string{
+		`{"a": 1,}`,
+		`{"a": 1 "target": 2}`,
+		`{"a" 1}`,
+		`{"a": [1}`,
+	}
+	for i, json := range bad {
+		s := NewScanner(bytes.NewBufferString(json))
+		s.ReadToken()
+		if _, err := s.SkipToKey("target"); err == nil {
+			t.Errorf("Bad case %d: Got no error, want one", i)
+		}
+	}
+}
+
+/*
+A dispatcher that picks the schema for each value in a stream by its type.
+*/
+func Example_scannerParseWith() {
+	type point struct {
+		X int64
+		Y int64
+	}
+
+	ptSchema := Struct(Prop("x", Integer()), Prop("y", Integer()))
+	if err := PrepareSchema(point{}, ptSchema); err != nil {
+		panic(err)
+	}
+	nameSchema := String(MinLen(1))
+
+	s := NewScanner(strings.NewReader(`{"x": 1, "y": 2} "origin" "" [3, 4]`))
+	for {
+		tok, err := s.PeekToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+
+		switch tok {
+		case TokenObjectBegin:
+			var pt point
+			if err := s.ParseWith(ptSchema, &pt); err != nil {
+				fmt.Println("bad point:", err)
+			} else {
+				fmt.Println("point:", pt.X, pt.Y)
+			}
+		case TokenString:
+			var name string
+			if err := s.ParseWith(nameSchema, &name); err != nil {
+				fmt.Println("bad name:", err)
+			} else {
+				fmt.Println("name:", name)
+			}
+		default:
+			fmt.Println("skipping", tok)
+			if err := s.SkipValue(); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	// Output:
+	// point: 1 2
+	// name: origin
+	// bad name: [{/ Must be at least 1 bytes long}]
+	// skipping [
+}
@@ -0,0 +1,76 @@
+package jsonv
+
+import (
+	"io"
+)
+
+/*
+A single JSON token that owns its bytes, so unlike those from
+Scanner.ReadToken it stays valid after later reads and can be shared between
+goroutines.
+*/
+type Token struct {
+	Type   TokenType
+	Value  []byte // the token's raw bytes, e.g. the quotes and escapes of a string
+	Offset int    // the position of the token's first byte in the input
+}
+
+/*
+Reads the tokens from a Scanner as Tokens, e.g. to tokenise the input once and
+inspect it in several goroutines at the same time.
+
+Every token's bytes are copied, which costs an allocation per token that
+ReadToken avoids, so this is much slower than using the Scanner directly and
+should only be used where the tokens need to outlive the next read.
+*/
+type TokenStream struct {
+	s *Scanner
+}
+
+func NewTokenStream(s *Scanner) *TokenStream {
+	return &TokenStream{s}
+}
+
+/*
+Reads the next token. Returns io.EOF, or any other read or parse error, once
+there are no more.
+
+The tokens are not checked to be in a valid order, e.g. "{]" is two tokens.
+*/
+func (ts *TokenStream) Next() (Token, error) {
+	tok, buf, err := ts.s.ReadToken()
+	if tok == TokenError {
+		return Token{}, err
+	}
+
+	val := make([]byte, len(buf))
+	copy(val, buf)
+	return Token{tok, val, ts.s.rcount - len(buf)}, nil
+}
+
+/*
+Sends every remaining token to each of chs, in order, then closes them and
+returns the error that stopped it, which is nil at the end of the input.
+
+Each Token's Value is shared by all of chs, so consumers must not modify it.
+*/
+func (ts *TokenStream) FanOut(chs ...chan<- Token) error {
+	defer func() {
+		for _, ch := range chs {
+			close(ch)
+		}
+	}()
+
+	for {
+		tok, err := ts.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		for _, ch := range chs {
+			ch <- tok
+		}
+	}
+}
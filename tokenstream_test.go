@@ -0,0 +1,79 @@
+package jsonv
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_TokenStreamFanOut(t *testing.T) {
+	json := `{"name": "Bob \"B\" Smith", "tags": ["a", "b"], "age": 42, "ok": true, "x": null}`
+	want := []Token{
+		{TokenObjectBegin, []byte(`{`), 0},
+		{TokenString, []byte(`"name"`), 1},
+		{TokenPropSep, []byte(`:`), 7},
+		{TokenString, []byte(`"Bob \"B\" Smith"`), 9},
+		{TokenItemSep, []byte(`,`), 26},
+		{TokenString, []byte(`"tags"`), 28},
+		{TokenPropSep, []byte(`:`), 34},
+		{TokenArrayBegin, []byte(`[`), 36},
+		{TokenString, []byte(`"a"`), 37},
+		{TokenItemSep, []byte(`,`), 40},
+		{TokenString, []byte(`"b"`), 42},
+		{TokenArrayEnd, []byte(`]`), 45},
+		{TokenItemSep, []byte(`,`), 46},
+		{TokenString, []byte(`"age"`), 48},
+		{TokenPropSep, []byte(`:`), 53},
+		{TokenNumber, []byte(`42`), 55},
+		{TokenItemSep, []byte(`,`), 57},
+		{TokenString, []byte(`"ok"`), 59},
+		{TokenPropSep, []byte(`:`), 63},
+		{TokenTrue, []byte(`true`), 65},
+		{TokenItemSep, []byte(`,`), 69},
+		{TokenString, []byte(`"x"`), 71},
+		{TokenPropSep, []byte(`:`), 74},
+		{TokenNull, []byte(`null`), 76},
+		{TokenObjectEnd, []byte(`}`), 80},
+	}
+
+	// reading a byte at a time makes sure the scanner's buffer is re-used
+	ts := NewTokenStream(NewScanner(&oneByteReader{bytes.NewReader([]byte(json))}))
+
+	// unbuffered, so the consumers run alongside the stream
+	chs := []chan Token{make(chan Token), make(chan Token)}
+	got := make([][]Token, len(chs))
+
+	var wg sync.WaitGroup
+	for i := range chs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for tok := range chs[i] {
+				got[i] = append(got[i], tok)
+			}
+		}(i)
+	}
+
+	if err := ts.FanOut(chs[0], chs[1]); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	for i := range got {
+		if !reflect.DeepEqual(got[i], want) {
+			t.Errorf("Consumer %d: Got %v, want %v", i, got[i], want)
+		}
+	}
+
+	// errors stop the stream
+	ch := make(chan Token, 10)
+	err := NewTokenStream(NewScanner(strings.NewReader(`[1, tru]`))).FanOut(ch)
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got %v, want a ParseError", err)
+	}
+	if n := len(ch); n != 3 {
+		t.Fatalf("Got %d tokens, want 3", n)
+	}
+}
@@ -1,6 +1,7 @@
 package jsonv
 
 import (
+	"fmt"
 	"reflect"
 )
 
@@ -23,6 +24,32 @@ type SchemaType interface {
 	Parse(Pather, *Scanner, interface{}) error
 }
 
+/*
+Returned when preparing a schema for a type reveals a bug in the schema's
+definition, e.g. two props that map to the same struct field.
+*/
+type SchemaConfigError struct {
+	e string
+}
+
+func NewSchemaConfigError(e string, args ...interface{}) error {
+	return &SchemaConfigError{fmt.Sprintf(e, args...)}
+}
+
+func (e *SchemaConfigError) Error() string {
+	return e.e
+}
+
+/*
+Returned by a SchemaType's Parse to indicate that the value it read should be
+treated as though the property were not present at all, e.g. the empty string
+a HTML form submits for an unfilled, optional number.
+
+Only Struct properties can be absent. Anywhere else, this is reported as a
+missing value.
+*/
+var ErrAbsent = fmt.Errorf("Value is absent")
+
 /*
 SchemaTypes can implement this to allow
 
@@ -34,3 +61,93 @@ ObjectProp.Types
 type PreparedSchemaType interface {
 	Prepare(reflect.Type) error
 }
+
+/*
+SchemaTypes can implement this to check a value, reporting the same errors as
+Parse, without storing it anywhere. See ValidatingParser.Validate.
+
+Those that don't are parsed into a temporary value instead, so implementing
+this is purely an optimisation.
+*/
+type ValidatingSchemaType interface {
+	Validate(Pather, *Scanner) error
+}
+
+/*
+Checks the next value in s against schema, which has been prepared for values
+of type t, without keeping it.
+*/
+func validateValue(schema SchemaType, t reflect.Type, path Pather, s *Scanner) error {
+	if vs, ok := schema.(ValidatingSchemaType); ok {
+		return vs.Validate(path, s)
+	}
+	return schema.Parse(path, s, reflect.New(t).Interface())
+}
+
+/*
+Reports a well-formed value of the wrong type, e.g. a string where a number is
+expected, as invalid rather than malformed, so the parse can continue. tok is
+the value's first token, which has already been read, and the rest of the value
+is skipped.
+*/
+func wrongType(path Pather, s *Scanner, tok TokenType, msg string) error {
+	if err := s._skipValue(tok); err != nil {
+		return err
+	}
+	return NewSingleVErr(path(), msg)
+}
+
+/*
+The type of JSON value a SchemaType accepts. Useful for documentation, building
+client-facing forms and error messages.
+*/
+type JSONType int
+
+const (
+	JSONAny JSONType = iota // accepts any JSON value, or it's unknown
+	JSONNull
+	JSONBoolean
+	JSONNumber
+	JSONString
+	JSONArray
+	JSONObject
+)
+
+func (t JSONType) String() string {
+	switch t {
+	case JSONNull:
+		return "null"
+	case JSONBoolean:
+		return "boolean"
+	case JSONNumber:
+		return "number"
+	case JSONString:
+		return "string"
+	case JSONArray:
+		return "array"
+	case JSONObject:
+		return "object"
+	default:
+		return "any"
+	}
+}
+
+/*
+SchemaTypes can implement this to report the type of JSON value they accept.
+
+All the built-in types implement this.
+*/
+type TypedSchemaType interface {
+	ExpectedType() JSONType
+}
+
+/*
+Returns the JSON type accepted by s, or JSONAny if s doesn't implement
+TypedSchemaType.
+*/
+func ExpectedTypeOf(s SchemaType) JSONType {
+	if ts, ok := s.(TypedSchemaType); ok {
+		return ts.ExpectedType()
+	}
+	return JSONAny
+}
@@ -0,0 +1,183 @@
+package jsonv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+/*
+Parses any JSON value into an interface{}, the same way encoding/json does, for
+data with no fixed shape, e.g. user supplied metadata:
+
+	object     map[string]interface{}
+	array      []interface{}
+	string     string
+	number     float64, or json.Number with UseNumber
+	boolean    bool
+	null       nil
+
+As a Map's value schema, Map(Any()), it'll take any object. Nesting is still
+limited by the Scanner's MaxDepth, MaxElements and MaxObjectKeys.
+*/
+type AnyParser struct {
+	useNumber bool
+}
+
+func Any() *AnyParser {
+	return &AnyParser{}
+}
+
+/*
+Stores numbers as a json.Number holding their exact text, rather than as a
+float64, which can't hold every int64, as Decoder.UseNumber does for
+encoding/json. This applies at any depth.
+*/
+func (p *AnyParser) UseNumber() *AnyParser {
+	p.useNumber = true
+	return p
+}
+
+func (p *AnyParser) ExpectedType() JSONType {
+	return JSONAny
+}
+
+func (p *AnyParser) Prepare(t reflect.Type) error {
+	if t.Kind() != reflect.Interface || t.NumMethod() != 0 {
+		return NewSchemaConfigError(ERROR_PREPARE_DEST, "interface{}", t)
+	}
+	return nil
+}
+
+func (p *AnyParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	ptrVal := reflect.ValueOf(v)
+	if ptrVal.Kind() != reflect.Ptr || ptrVal.IsNil() || ptrVal.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf(ERROR_BAD_ANY_DEST, ptrVal.Type())
+	}
+
+	val, err := p.value(path, s)
+	if val != nil {
+		ptrVal.Elem().Set(reflect.ValueOf(val))
+	} else if err == nil {
+		ptrVal.Elem().Set(reflect.Zero(ptrVal.Elem().Type()))
+	}
+	return err
+}
+
+func (p *AnyParser) Validate(path Pather, s *Scanner) error {
+	_, err := p.value(path, s)
+	return err
+}
+
+/*
+Reads the next value. If there's a ValidationError, e.g. a number too big for
+a float64, the rest of the value is still returned along with it.
+*/
+func (p *AnyParser) value(path Pather, s *Scanner) (interface{}, error) {
+	tok, err := s.PeekToken()
+	if tok == TokenError {
+		return nil, err
+	}
+
+	switch tok {
+	case TokenObjectBegin:
+		obj := make(map[string]interface{})
+		err := forEachPair(path, s, func(keyPath Pather, key string) error {
+			val, err := p.value(keyPath, s)
+			obj[key] = val
+			return err
+		})
+		return obj, err
+	case TokenArrayBegin:
+		return p.array(path, s)
+	}
+
+	tok, buf, err := s.ReadToken()
+	switch tok {
+	default:
+		return nil, err
+	case TokenNull:
+		return nil, nil
+	case TokenTrue:
+		return true, nil
+	case TokenFalse:
+		return false, nil
+	case TokenString:
+		str, ok := Unquote(buf)
+		if !ok {
+			return nil, NewSingleVErr(path(), "Invalid string")
+		}
+		return str, nil
+	case TokenNumber:
+		if p.useNumber {
+			return json.Number(buf), nil
+		}
+		f, _ := strconv.ParseFloat(string(buf), 64)
+		if math.IsInf(f, 0) {
+			return nil, NewSingleVErr(path(), ERROR_FLOAT_NON_FINITE)
+		}
+		return f, nil
+	}
+}
+
+/*
+Reads an array, see value.
+*/
+func (p *AnyParser) array(path Pather, s *Scanner) (interface{}, error) {
+	// read the '['
+	if _, _, err := s.ReadToken(); err != nil {
+		return nil, err
+	}
+	if err := s.enter(); err != nil {
+		return nil, err
+	}
+	defer s.leave()
+
+	var errs ValidationError
+	arr := []interface{}{}
+	i := 0
+	itemPath := func() string {
+		return path() + strconv.Itoa(i) + "/"
+	}
+
+	for ; ; i++ {
+		// read a value, or ']'
+		if tok, err := s.PeekToken(); tok == TokenError {
+			return nil, err
+		} else if tok == TokenArrayEnd {
+			s.ReadToken()
+			if i > 0 && !s.allowTrailingComma {
+				return nil, NewParseError(ERROR_TRAILING_COMMA_ARR)
+			}
+			break
+		} else if err := s.element(i, 0); err != nil {
+			return nil, err
+		}
+
+		val, err := p.value(itemPath, s)
+		if verr, ok := err.(ValidationError); ok {
+			if errs, err = s.addErrors(errs, verr); err != nil {
+				return nil, err
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		arr = append(arr, val)
+
+		// we want a , or a ]
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return nil, err
+		} else if tok == TokenArrayEnd {
+			break
+		} else if tok != TokenItemSep {
+			return nil, NewParseError("After element %d, expected ',' or ']' not %v", i, tok)
+		}
+	}
+
+	if len(errs) > 0 {
+		return arr, errs
+	}
+	return arr, nil
+}
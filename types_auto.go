@@ -0,0 +1,122 @@
+package jsonv
+
+import (
+	"reflect"
+)
+
+var (
+	stringType  = reflect.TypeOf("")
+	boolType    = reflect.TypeOf(false)
+	float32Type = reflect.TypeOf(float32(0))
+	float64Type = reflect.TypeOf(float64(0))
+	bytesType   = reflect.TypeOf([]byte(nil))
+)
+
+/*
+Works out which built-in parser to use from the destination type when it's
+prepared, for one-off parsing where the defaults will do, e.g.
+
+	var p Person
+	err := Parser(&p, Auto()).Parse(r, &p)
+
+Types are mapped to parsers as follows:
+
+	string                String()
+	bool                  Boolean()
+	int*, uint*           Integer(), including named types, e.g. `type Color int`
+	float32, float64      Float()
+	time.Time             DateTime()
+	[]byte                Base64Bytes(), as encoding/json does
+	[]T, [N]T             Slice(Auto())
+	map[string]T          Map(Auto())
+	structs               Struct() with a Prop(name, Auto()) for every field
+
+Any type registered with RegisterType uses the registered schema instead. As
+with Struct, non-pointer fields are required. There are no validators, build
+the schema by hand where they're needed.
+*/
+type AutoParser struct {
+	schema SchemaType
+	typ    reflect.Type
+
+	// the structs already being prepared, shared with any nested Autos so that
+	// recursive types use the same parser rather than recursing forever
+	seen map[reflect.Type]SchemaType
+}
+
+func Auto() *AutoParser {
+	return &AutoParser{}
+}
+
+func (p *AutoParser) ExpectedType() JSONType {
+	return ExpectedTypeOf(p.schema)
+}
+
+func (p *AutoParser) Prepare(t reflect.Type) error {
+	if p.seen == nil {
+		p.seen = make(map[reflect.Type]SchemaType)
+	}
+	p.typ = t
+
+	if s, ok := p.seen[t]; ok {
+		// already being prepared further up
+		p.schema = s
+		return nil
+	} else if s, ok := registeredSchema(t); ok {
+		p.schema = s
+		return prepareSchema(t, s)
+	}
+
+	switch {
+	case t == dateTimeType:
+		p.schema = DateTime()
+	case t == stringType:
+		p.schema = String()
+	case t == boolType:
+		p.schema = Boolean()
+	case t == float32Type, t == float64Type:
+		p.schema = Float()
+	case t == bytesType:
+		p.schema = Base64Bytes()
+	default:
+		switch t.Kind() {
+		default:
+			return NewSchemaConfigError(ERROR_PREPARE_DEST, "a type Auto supports", t)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			p.schema = Integer()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			p.schema = Integer()
+		case reflect.Slice, reflect.Array:
+			p.schema = Slice(p.nested())
+		case reflect.Map:
+			p.schema = Map(p.nested())
+		case reflect.Struct:
+			fields := typeFields(t)
+			props := make([]StructPropInfo, len(fields))
+			for i, f := range fields {
+				props[i] = Prop(f.name, p.nested())
+			}
+
+			st := Struct(props...)
+			p.seen[t] = st
+			p.schema = st
+		}
+	}
+
+	return prepareSchema(t, p.schema)
+}
+
+/*
+An Auto for a value nested within this one.
+*/
+func (p *AutoParser) nested() *AutoParser {
+	return &AutoParser{seen: p.seen}
+}
+
+func (p *AutoParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	return p.schema.Parse(path, s, v)
+}
+
+func (p *AutoParser) Validate(path Pather, s *Scanner) error {
+	return validateValue(p.schema, p.typ, path, s)
+}
@@ -3,6 +3,8 @@ package jsonv
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 /*
@@ -10,29 +12,54 @@ Parses true/false JSON values into a *bool/*string or bool/string struct field.
 
 For strings, the literal text "false"/"true", without quotes, is assigned to the
 string.
+
+By default only JSON's true and false are accepted, see WithTruthy and
+WithFalsy to accept strings too.
 */
 type BooleanParser struct {
+	truthy []string
+	falsy  []string
 }
 
 func Boolean() *BooleanParser {
 	return &BooleanParser{}
 }
 
+/*
+Accepts JSON strings matching any of vals, ignoring case, as true, e.g.
+WithTruthy("yes", "on", "1") for config style input. Strings that match neither
+these nor those given to WithFalsy are invalid.
+*/
+func (p *BooleanParser) WithTruthy(vals ...string) *BooleanParser {
+	p.truthy = append(p.truthy, vals...)
+	return p
+}
+
+/*
+Accepts JSON strings matching any of vals, ignoring case, as false. See
+WithTruthy.
+*/
+func (p *BooleanParser) WithFalsy(vals ...string) *BooleanParser {
+	p.falsy = append(p.falsy, vals...)
+	return p
+}
+
+func (p *BooleanParser) ExpectedType() JSONType {
+	return JSONBoolean
+}
+
 func (p *BooleanParser) Prepare(t reflect.Type) error {
 	if t.Kind() != reflect.Bool && t.Kind() != reflect.String {
-		return fmt.Errorf("Want bool not %v", t)
+		return fmt.Errorf(ERROR_PREPARE_DEST, "bool", t)
 	}
 
 	return nil
 }
 
 func (p *BooleanParser) Parse(path Pather, s *Scanner, v interface{}) error {
-	tok, buf, err := s.ReadToken()
-	// wasn't the correct type
-	if tok == TokenError {
+	b, err := p.parse(path, s)
+	if err != nil {
 		return err
-	} else if tok != TokenTrue && tok != TokenFalse {
-		return NewSingleVErr(path(), fmt.Sprintf(ERROR_INVALID_BOOL, string(buf)))
 	}
 
 	// now assign the value with whatever precision we can
@@ -40,10 +67,46 @@ func (p *BooleanParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	default:
 		return fmt.Errorf(ERROR_BAD_BOOL_DEST, reflect.TypeOf(v), path())
 	case *string:
-		*t = string(buf)
+		*t = strconv.FormatBool(b)
 	case *bool:
-		*t = buf[0] == 't'
+		*t = b
 	}
 
 	return nil
 }
+
+func (p *BooleanParser) Validate(path Pather, s *Scanner) error {
+	_, err := p.parse(path, s)
+	return err
+}
+
+/*
+Reads a boolean, or one of the strings mapped to one.
+*/
+func (p *BooleanParser) parse(path Pather, s *Scanner) (bool, error) {
+	tok, buf, err := s.ReadToken()
+	// wasn't the correct type
+	if tok == TokenError {
+		return false, err
+	} else if tok == TokenTrue || tok == TokenFalse {
+		return tok == TokenTrue, nil
+	} else if tok == TokenString && (len(p.truthy) > 0 || len(p.falsy) > 0) {
+		if str, ok := Unquote(buf); ok {
+			if matchesAny(str, p.truthy) {
+				return true, nil
+			} else if matchesAny(str, p.falsy) {
+				return false, nil
+			}
+		}
+	}
+	return false, wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_BOOL, string(buf)))
+}
+
+func matchesAny(s string, vals []string) bool {
+	for _, v := range vals {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package jsonv
 
 import (
+	"encoding/base64"
 	"fmt"
 	"reflect"
 )
@@ -18,9 +19,13 @@ func Bytes(vs ...BytesValidator) *ByteSliceParser {
 	return &ByteSliceParser{vs}
 }
 
+func (p *ByteSliceParser) ExpectedType() JSONType {
+	return JSONString
+}
+
 func (p *ByteSliceParser) Prepare(t reflect.Type) error {
 	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
-		return fmt.Errorf("Want []byte not %v", t)
+		return fmt.Errorf(ERROR_PREPARE_DEST, "[]byte", t)
 	}
 
 	return nil
@@ -31,7 +36,7 @@ func (p *ByteSliceParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	if tok == TokenError {
 		return err
 	} else if tok != TokenString {
-		return NewSingleVErr(path(), fmt.Sprintf(ERROR_INVALID_STRING, string(buf)))
+		return wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_STRING, string(buf)))
 	}
 
 	if bdest, ok := v.(*[]byte); !ok {
@@ -55,7 +60,88 @@ func (p *ByteSliceParser) Parse(path Pather, s *Scanner, v interface{}) error {
 			return errs
 		}
 
-		*bdest = buff
+		// without any escapes, buff is part of the scanner's buffer
+		*bdest = append([]byte(nil), buff...)
+	}
+
+	return nil
+}
+
+/*
+Parses base64 encoded strings, using the standard encoding with padding (see
+RFC 4648), into byte slices. This is how encoding/json represents []byte.
+
+Invalid base64 is reported as a validation error. The validators are applied to
+the decoded bytes.
+*/
+type Base64ByteSliceParser struct {
+	enc *base64.Encoding
+	vs  []BytesValidator
+}
+
+func Base64Bytes(vs ...BytesValidator) *Base64ByteSliceParser {
+	return &Base64ByteSliceParser{base64.StdEncoding, vs}
+}
+
+/*
+Uses enc instead of the standard encoding, e.g. base64.RawURLEncoding.
+*/
+func (p *Base64ByteSliceParser) Encoding(enc *base64.Encoding) *Base64ByteSliceParser {
+	p.enc = enc
+	return p
+}
+
+func (p *Base64ByteSliceParser) ExpectedType() JSONType {
+	return JSONString
+}
+
+func (p *Base64ByteSliceParser) Prepare(t reflect.Type) error {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+		return fmt.Errorf(ERROR_PREPARE_DEST, "[]byte", t)
+	}
+
+	return nil
+}
+
+func (p *Base64ByteSliceParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	tok, buf, err := s.ReadToken()
+	if tok == TokenError {
+		return err
+	} else if tok != TokenString {
+		return wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_STRING, string(buf)))
+	}
+
+	if bdest, ok := v.(*[]byte); !ok {
+		return fmt.Errorf(ERROR_BAD_BYTE_DEST, reflect.TypeOf(v), path())
+	} else {
+		var errs ValidationError
+
+		// some encoders escape the '/', e.g. "\/"
+		encoded, ok := UnquoteBytes(buf)
+		if !ok {
+			return errs.Add(path(), "Invalid string")
+		}
+
+		// decoding into a new slice also means we don't alias the scanner
+		decoded := make([]byte, p.enc.DecodedLen(len(encoded)))
+		n, err := p.enc.Decode(decoded, encoded)
+		if err != nil {
+			return errs.Add(path(), ERROR_INVALID_BASE64)
+		}
+		decoded = decoded[:n]
+
+		// validate the contents
+		for _, v := range p.vs {
+			if err := v.ValidateBytes(decoded); err != nil {
+				errs = errs.Add(path(), err.Error())
+			}
+		}
+
+		if len(errs) > 0 {
+			return errs
+		}
+
+		*bdest = decoded
 	}
 
 	return nil
@@ -75,9 +161,13 @@ func RawBytes() *RawByteSliceParser {
 	return &RawByteSliceParser{}
 }
 
+func (p *RawByteSliceParser) ExpectedType() JSONType {
+	return JSONString
+}
+
 func (p *RawByteSliceParser) Prepare(t reflect.Type) error {
 	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
-		return fmt.Errorf("Want []byte not %v", t)
+		return fmt.Errorf(ERROR_PREPARE_DEST, "[]byte", t)
 	}
 
 	return nil
@@ -88,7 +178,7 @@ func (p *RawByteSliceParser) Parse(path Pather, s *Scanner, v interface{}) error
 	if tok == TokenError {
 		return err
 	} else if tok != TokenString {
-		return NewSingleVErr(path(), fmt.Sprintf(ERROR_INVALID_STRING, string(buf)))
+		return wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_STRING, string(buf)))
 	}
 
 	if bdest, ok := v.(*[]byte); !ok {
@@ -101,3 +191,46 @@ func (p *RawByteSliceParser) Parse(path Pather, s *Scanner, v interface{}) error
 
 	return nil
 }
+
+/*
+Same as RawBytes, but avoids copying the value when the Scanner reads directly
+from a caller provided buffer (see NewBytesScanner and
+ValidatingParser.ParseBytes).
+
+WARNING: When aliasing, the parsed []byte references the input buffer. Any
+later modification of the input will be visible through the parsed value and
+vice versa. Only use this when the input is stable for the lifetime of the
+parsed value.
+
+When used with a streaming Scanner, the value is copied exactly as RawBytes
+does, as the Scanner's internal buffer will be re-used.
+*/
+type RawByteSliceNoCopyParser struct {
+	RawByteSliceParser
+}
+
+func RawBytesNoCopy() *RawByteSliceNoCopyParser {
+	return &RawByteSliceNoCopyParser{}
+}
+
+func (p *RawByteSliceNoCopyParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	if !s.IsFixed() {
+		return p.RawByteSliceParser.Parse(path, s, v)
+	}
+
+	tok, buf, err := s.ReadToken()
+	if tok == TokenError {
+		return err
+	} else if tok != TokenString {
+		return wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_STRING, string(buf)))
+	}
+
+	if bdest, ok := v.(*[]byte); !ok {
+		return fmt.Errorf(ERROR_BAD_BYTE_DEST, reflect.TypeOf(v), path())
+	} else {
+		// cap the slice so an append can't overwrite the rest of the input
+		*bdest = buf[1 : len(buf)-1 : len(buf)-1]
+	}
+
+	return nil
+}
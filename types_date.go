@@ -26,19 +26,44 @@ func (f DateValidatorFunc) ValidateDate(t time.Time) error {
 /*
 Parses JSON strings value and stores it in a Go time.Time.
 
-The string must be in the format "yyyy-mm-dd"
+The string must be in the format "yyyy-mm-dd", see DateFormat for others.
 */
 type DateParser struct {
-	vs []DateValidator
+	layout      string
+	vs          []DateValidator
+	emptyAbsent bool
 }
 
 func Date(vs ...DateValidator) *DateParser {
-	return &DateParser{vs}
+	return &DateParser{layout: date_fmt, vs: vs}
+}
+
+/*
+Parses JSON string values using the given time.Parse style layout, e.g.
+DateFormat("02/01/2006") for "25/12/2021" or DateFormat("Jan 2, 2006").
+
+The layout should not include the surrounding quotes.
+*/
+func DateFormat(layout string, vs ...DateValidator) *DateParser {
+	return &DateParser{layout: `"` + layout + `"`, vs: vs}
+}
+
+/*
+Treat an empty string, i.e. "", as though the value were absent rather than
+invalid. See ErrAbsent.
+*/
+func (p *DateParser) EmptyAsAbsent() *DateParser {
+	p.emptyAbsent = true
+	return p
+}
+
+func (p *DateParser) ExpectedType() JSONType {
+	return JSONString
 }
 
 func (p *DateParser) Prepare(t reflect.Type) error {
 	if t != dateType {
-		return fmt.Errorf("Want time.Time not %v", t)
+		return fmt.Errorf(ERROR_PREPARE_DEST, "time.Time", t)
 	}
 
 	return nil
@@ -48,8 +73,10 @@ func (p *DateParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	tok, buf, err := s.ReadToken()
 	if tok == TokenError {
 		return err
+	} else if p.emptyAbsent && len(buf) == 2 && tok == TokenString {
+		return ErrAbsent
 	} else if tok != TokenString {
-		return NewParseError(ERROR_INVALID_DATE, string(buf))
+		return wrongType(path, s, tok, ERROR_INVALID_DATE)
 	}
 
 	if dest, ok := v.(*time.Time); !ok {
@@ -57,7 +84,7 @@ func (p *DateParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	} else {
 		var errs ValidationError
 
-		val, err := time.Parse(date_fmt, string(buf))
+		val, err := time.Parse(p.layout, string(buf))
 		if err != nil {
 			errs = errs.Add(path(), err.Error())
 			return errs
@@ -6,7 +6,12 @@ import (
 	"time"
 )
 
-const datetime_fmt = `"2006-01-02 15:04:05"`
+// RFC 3339, fractional seconds are accepted when parsing even though the layout
+// doesn't have them
+const datetime_fmt = `"2006-01-02T15:04:05Z07:00"`
+
+// what DateTime used to accept, still accepted for backwards compatibility
+const datetime_legacy_fmt = `"2006-01-02 15:04:05"`
 
 var dateTimeType = reflect.TypeOf(time.Now())
 
@@ -26,19 +31,53 @@ func (f DateTimeValidatorFunc) ValidateDateTime(t time.Time) error {
 /*
 Parses JSON strings value and stores it in a Go time.Time.
 
-The string must be in the format `"2016-03-10T23:00:00.000Z"`
+The string must be in RFC 3339 format, e.g. `"2016-03-10T23:00:00.000Z"` or
+`"2016-03-10T23:00:00+10:00"`, with or without fractional seconds. For
+backwards compatibility `"2016-03-10 23:00:00"`, in UTC, is also accepted. See
+DateTimeInLocation for other formats.
 */
 type DateTimeParser struct {
-	vs []DateTimeValidator
+	layouts     []string // tried in order
+	loc         *time.Location
+	vs          []DateTimeValidator
+	emptyAbsent bool
 }
 
 func DateTime(vs ...DateTimeValidator) *DateTimeParser {
-	return &DateTimeParser{vs}
+	return &DateTimeParser{layouts: []string{datetime_fmt, datetime_legacy_fmt}, loc: time.UTC, vs: vs}
+}
+
+/*
+Parses JSON string values using the given time.Parse style layout, interpreting
+any value without timezone information as being in loc.
+
+The layout should not include the surrounding quotes, e.g.
+DateTimeInLocation("2006-01-02 15:04:05", nyc) will read "2021-06-01 09:00:00"
+as 9am in New York, not 9am UTC.
+*/
+func DateTimeInLocation(layout string, loc *time.Location, vs ...DateTimeValidator) *DateTimeParser {
+	if loc == nil {
+		panic(fmt.Errorf("Location must not be nil"))
+	}
+	return &DateTimeParser{layouts: []string{`"` + layout + `"`}, loc: loc, vs: vs}
+}
+
+/*
+Treat an empty string, i.e. "", as though the value were absent rather than
+invalid. See ErrAbsent.
+*/
+func (p *DateTimeParser) EmptyAsAbsent() *DateTimeParser {
+	p.emptyAbsent = true
+	return p
+}
+
+func (p *DateTimeParser) ExpectedType() JSONType {
+	return JSONString
 }
 
 func (p *DateTimeParser) Prepare(t reflect.Type) error {
 	if t != dateTimeType {
-		return fmt.Errorf("Want time.Time not %v", t)
+		return fmt.Errorf(ERROR_PREPARE_DEST, "time.Time", t)
 	}
 
 	return nil
@@ -48,8 +87,10 @@ func (p *DateTimeParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	tok, buf, err := s.ReadToken()
 	if tok == TokenError {
 		return err
+	} else if p.emptyAbsent && len(buf) == 2 && tok == TokenString {
+		return ErrAbsent
 	} else if tok != TokenString {
-		return NewParseError(ERROR_INVALID_DATE_TIME, string(buf))
+		return wrongType(path, s, tok, ERROR_INVALID_DATE_TIME)
 	}
 
 	if dest, ok := v.(*time.Time); !ok {
@@ -57,7 +98,13 @@ func (p *DateTimeParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	} else {
 		var errs ValidationError
 
-		val, err := time.Parse(datetime_fmt, string(buf))
+		// any error is from the first layout, the one that's documented
+		val, err := time.ParseInLocation(p.layouts[0], string(buf), p.loc)
+		for i := 1; err != nil && i < len(p.layouts); i++ {
+			if v, lerr := time.ParseInLocation(p.layouts[i], string(buf), p.loc); lerr == nil {
+				val, err = v, nil
+			}
+		}
 		if err != nil {
 			errs = errs.Add(path(), err.Error())
 			return errs
@@ -0,0 +1,136 @@
+package jsonv
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+/*
+Parses either a JSON string or number into a Go time.Time, for upstreams that
+don't agree on how to send times.
+
+Strings are parsed as by DateTime, or with the layout given to Layout. Numbers
+must be integers and are read as a Unix timestamp, in seconds by default, or in
+the unit given to UnixUnit.
+
+This is inherently ambiguous. Nothing in the value itself says which format a
+client intended, e.g. a string holding only digits is never read as a Unix
+timestamp and a timestamp in milliseconds, sent to a parser expecting seconds,
+is still a valid (if very distant) time. Prefer DateTime where the format is
+under your control.
+*/
+type FlexibleTimeParser struct {
+	str  *DateTimeParser
+	unit time.Duration
+}
+
+func FlexibleTime(vs ...DateTimeValidator) *FlexibleTimeParser {
+	return &FlexibleTimeParser{str: DateTime(vs...), unit: time.Second}
+}
+
+/*
+Sets the layout used to parse string values, see DateTimeInLocation. The
+location is also used for times read from numbers.
+*/
+func (p *FlexibleTimeParser) Layout(layout string, loc *time.Location) *FlexibleTimeParser {
+	// only the layout and location change, so options like EmptyAsAbsent are kept
+	in := DateTimeInLocation(layout, loc)
+	p.str.layouts, p.str.loc = in.layouts, in.loc
+	return p
+}
+
+/*
+Sets the unit of numeric values, e.g. time.Millisecond for timestamps from
+JavaScript's Date.now(). It must be a whole multiple or divisor of a second.
+*/
+func (p *FlexibleTimeParser) UnixUnit(unit time.Duration) *FlexibleTimeParser {
+	if unit <= 0 || (unit < time.Second && time.Second%unit != 0) || (unit > time.Second && unit%time.Second != 0) {
+		panic(fmt.Errorf("Unix unit must divide or be a multiple of a second, not %v", unit))
+	}
+	p.unit = unit
+	return p
+}
+
+/*
+Treat an empty string, i.e. "", as though the value were absent rather than
+invalid. See ErrAbsent.
+*/
+func (p *FlexibleTimeParser) EmptyAsAbsent() *FlexibleTimeParser {
+	p.str.EmptyAsAbsent()
+	return p
+}
+
+func (p *FlexibleTimeParser) ExpectedType() JSONType {
+	return JSONAny
+}
+
+func (p *FlexibleTimeParser) Prepare(t reflect.Type) error {
+	return p.str.Prepare(t)
+}
+
+func (p *FlexibleTimeParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	tok, err := s.PeekToken()
+	if tok == TokenError {
+		return err
+	} else if tok == TokenString {
+		return p.str.Parse(path, s, v)
+	} else if tok != TokenNumber {
+		tok, buf, _ := s.ReadToken()
+		return wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_FLEXIBLE_TIME, string(buf)))
+	}
+
+	_, buf, err := s.ReadToken()
+	if err != nil {
+		return err
+	}
+
+	if dest, ok := v.(*time.Time); !ok {
+		return NewParseError(ERROR_BAD_DATE_TIME_DEST, reflect.TypeOf(v), path())
+	} else {
+		var errs ValidationError
+
+		val, ok := p.unixTime(buf)
+		if !ok {
+			errs = errs.Add(path(), fmt.Sprintf(ERROR_INVALID_UNIX_TIME, string(buf)))
+			return errs
+		}
+
+		// validate the value
+		for _, v := range p.str.vs {
+			if err := v.ValidateDateTime(val); err != nil {
+				errs = errs.Add(path(), err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+
+		*dest = val
+	}
+
+	return nil
+}
+
+/*
+Converts an integer count of p.unit since the Unix epoch to a time.Time.
+*/
+func (p *FlexibleTimeParser) unixTime(buf []byte) (time.Time, bool) {
+	n, err := strconv.ParseInt(string(buf), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	if p.unit >= time.Second {
+		mul := int64(p.unit / time.Second)
+		if n > math.MaxInt64/mul || n < math.MinInt64/mul {
+			return time.Time{}, false
+		}
+		return time.Unix(n*mul, 0).In(p.str.loc), true
+	}
+
+	per := int64(time.Second / p.unit)
+	return time.Unix(n/per, (n%per)*int64(p.unit)).In(p.str.loc), true
+}
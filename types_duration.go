@@ -0,0 +1,243 @@
+package jsonv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+/*
+Parses JSON string Go durations, e.g. "1500ms" or "2h45m", as accepted by
+time.ParseDuration, and stores them in a Go time.Duration.
+*/
+type DurationParser struct {
+	vs []DurationValidator
+}
+
+func Duration(vs ...DurationValidator) *DurationParser {
+	return &DurationParser{vs: vs}
+}
+
+func (p *DurationParser) ExpectedType() JSONType {
+	return JSONString
+}
+
+func (p *DurationParser) Prepare(t reflect.Type) error {
+	return prepareDuration(t)
+}
+
+func (p *DurationParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	return parseDuration(path, s, v, parseGoDuration, p.vs)
+}
+
+/*
+Parses JSON string ISO 8601 durations, e.g. "PT1H30M" or "P1DT2H", and stores
+them in a Go time.Duration.
+
+Weeks, days, hours, minutes and seconds are supported, with days always 24
+hours long. Years and months are rejected as they vary in length. The smallest
+unit given may have a fraction, e.g. "PT1.5S", and the duration may start with a
+'-' to make it negative.
+*/
+type ISODurationParser struct {
+	vs []DurationValidator
+}
+
+func ISODuration(vs ...DurationValidator) *ISODurationParser {
+	return &ISODurationParser{vs: vs}
+}
+
+func (p *ISODurationParser) ExpectedType() JSONType {
+	return JSONString
+}
+
+func (p *ISODurationParser) Prepare(t reflect.Type) error {
+	return prepareDuration(t)
+}
+
+func (p *ISODurationParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	return parseDuration(path, s, v, parseISODuration, p.vs)
+}
+
+/*
+Only time.Duration itself, not other int64 types, can hold a duration.
+*/
+func prepareDuration(t reflect.Type) error {
+	if t != durationType {
+		return fmt.Errorf(ERROR_PREPARE_DEST, "time.Duration", t)
+	}
+
+	return nil
+}
+
+/*
+Reads a string and converts it to a duration with parse, then validates it with
+vs and stores it in v.
+*/
+func parseDuration(path Pather, s *Scanner, v interface{}, parse func(string) (time.Duration, error), vs []DurationValidator) error {
+	tok, buf, err := s.ReadToken()
+	if tok == TokenError {
+		return err
+	} else if tok != TokenString {
+		return wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_DURATION, string(buf)))
+	}
+
+	if dest, ok := v.(*time.Duration); !ok {
+		return NewParseError(ERROR_BAD_DURATION_DEST, reflect.TypeOf(v), path())
+	} else {
+		var errs ValidationError
+
+		str, _ := Unquote(buf)
+		val, err := parse(str)
+		if err != nil {
+			errs = errs.Add(path(), err.Error())
+			return errs
+		}
+
+		// validate the value
+		for _, v := range vs {
+			if err := v.ValidateDuration(val); err != nil {
+				errs = errs.Add(path(), err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+
+		*dest = val
+	}
+
+	return nil
+}
+
+/*
+Parses a Go duration, replacing time.ParseDuration's error with a friendlier
+one.
+*/
+func parseGoDuration(str string) (time.Duration, error) {
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf(ERROR_INVALID_GO_DURATION, str)
+	}
+	return d, nil
+}
+
+/*
+A duration designator and how long it is, 0 for those that vary.
+*/
+type isoUnit struct {
+	designator byte
+	size       time.Duration
+}
+
+var (
+	isoDateUnits = []isoUnit{{'Y', 0}, {'M', 0}, {'W', 7 * 24 * time.Hour}, {'D', 24 * time.Hour}}
+	isoTimeUnits = []isoUnit{{'H', time.Hour}, {'M', time.Minute}, {'S', time.Second}}
+)
+
+/*
+Parses an ISO 8601 duration, see ISODurationParser for what's supported.
+*/
+func parseISODuration(str string) (time.Duration, error) {
+	invalid := fmt.Errorf(ERROR_INVALID_ISO_DURATION, str)
+
+	s := str
+	neg := len(s) > 0 && s[0] == '-'
+	if neg {
+		s = s[1:]
+	}
+	if len(s) == 0 || s[0] != 'P' {
+		return 0, invalid
+	}
+	s = s[1:]
+
+	var total time.Duration
+	units := isoDateUnits
+	next := 0       // the first of units allowed next, as they must be in order
+	inTime := false // whether we're past the 'T'
+	found := false  // whether there's been any number at all
+	frac := false   // whether the last number had a fraction
+	for len(s) > 0 {
+		if s[0] == 'T' {
+			// the time part, which can't be empty
+			if inTime || len(s) == 1 {
+				return 0, invalid
+			}
+			units, next, inTime = isoTimeUnits, 0, true
+			s = s[1:]
+			continue
+		}
+		if frac {
+			// only the smallest unit may have a fraction
+			return 0, invalid
+		}
+
+		// the number, with an optional fraction
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, invalid
+		}
+		whole, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, invalid
+		}
+		var f float64
+		if i < len(s) && (s[i] == '.' || s[i] == ',') {
+			j := i + 1
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			if j == i+1 {
+				return 0, invalid
+			}
+			f, _ = strconv.ParseFloat("0."+s[i+1:j], 64)
+			frac = true
+			i = j
+		}
+		if i == len(s) {
+			return 0, invalid
+		}
+
+		// then its designator
+		u := next
+		for u < len(units) && units[u].designator != s[i] {
+			u++
+		}
+		if u == len(units) {
+			return 0, invalid
+		}
+		size := units[u].size
+		if size == 0 {
+			return 0, errors.New(ERROR_ISO_DURATION_YEARS)
+		}
+
+		if whole > int64(math.MaxInt64/size) {
+			return 0, invalid
+		}
+		d := time.Duration(whole)*size + time.Duration(f*float64(size))
+		if d < 0 || d > math.MaxInt64-total {
+			return 0, invalid
+		}
+		total += d
+
+		next = u + 1
+		found = true
+		s = s[i+1:]
+	}
+	if !found {
+		return 0, invalid
+	}
+
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
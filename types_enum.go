@@ -1,8 +1,12 @@
 package jsonv
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -11,44 +15,111 @@ Parses a value using the provided parser and then ensures it is one of the
 provided values.
 
 Makes use of reflect.DeepEqual for equality checking, so using simple types is
-recommended for both sanity and performance.
+recommended for both sanity and performance. See WithEqual to change that.
 */
 type EnumParser struct {
 	schema      SchemaType    // how do we parse it
 	allowedVals []interface{} // what values are acceptable
-	invalidMsg  string        // pre-built "value not valid" error
+	vals        []interface{} // allowedVals converted to the field's type, see Prepare
+	foldCase    bool
+	names       map[int64]string // see EnumNamed
+	equal       func(allowed, got interface{}) bool
 }
 
+/*
+The most allowed values that will be listed in an error message.
+*/
+const enumMsgMaxVals = 10
+
 /*
 SchemaType must work with the types of the provided values.
 
-The provided values must all have the same underlying types.
+The provided values must all be convertible to the field's type, and are
+converted to it before being compared, e.g. int(2) matches an int64 field's 2.
 
 Any of the above issues will be reported when Prepare is called.
 */
 func Enum(s SchemaType, vals ...interface{}) *EnumParser {
-	// Get a string representation of each.
-	// TODO: Check if imps MarshalJSON and use that representation.
-	var parts []string
+	return &EnumParser{schema: s, allowedVals: vals}
+}
+
+/*
+An Enum of integers, e.g. iota constants, where each allowed value has a name
+that's used in place of the number in error messages:
+
+	type Color int
+
+	const (
+		Red Color = iota
+		Green
+		Blue
+	)
+
+	EnumNamed(Integer(), map[int64]string{0: "Red", 1: "Green", 2: "Blue"})
+
+The integer is still what's stored, and the names are listed in numeric order.
+*/
+func EnumNamed(s SchemaType, names map[int64]string) *EnumParser {
+	vals := make([]int64, 0, len(names))
+	for v := range names {
+		vals = append(vals, v)
+	}
+	sort.Slice(vals, func(i, j int) bool { return vals[i] < vals[j] })
+
+	p := &EnumParser{schema: s, names: names}
 	for _, v := range vals {
-		parts = append(parts, fmt.Sprint(v))
+		p.allowedVals = append(p.allowedVals, v)
 	}
+	return p
+}
 
-	return &EnumParser{s, vals, fmt.Sprintf("Must be one of: %s", strings.Join(parts, ","))}
+/*
+Match string values ignoring case, e.g. "ACTIVE" for "active", storing the
+allowed value rather than the one given so the result is always in the same
+form. This has no effect on enums of other types.
+*/
+func (p *EnumParser) FoldCase() *EnumParser {
+	p.foldCase = true
+	return p
+}
+
+/*
+Compares values with fn rather than reflect.DeepEqual, for types with their own
+idea of equality, e.g. structs where only an id field matters:
+
+	Enum(currencySchema, AUD, NZD).WithEqual(func(allowed, got interface{}) bool {
+		return allowed.(Currency).Code == got.(Currency).Code
+	})
+
+fn is called with each allowed value, as given to Enum, and the parsed value,
+until it returns true. The parsed value is kept as is. This replaces FoldCase,
+and the types don't need to be comparable.
+*/
+func (p *EnumParser) WithEqual(fn func(allowed, got interface{}) bool) *EnumParser {
+	p.equal = fn
+	return p
+}
+
+func (p *EnumParser) ExpectedType() JSONType {
+	return ExpectedTypeOf(p.schema)
 }
 
 func (p *EnumParser) Prepare(t reflect.Type) error {
-	if !t.Comparable() {
+	if !t.Comparable() && p.equal == nil {
 		return fmt.Errorf("Field must be comparable")
 	}
 
-	// check that all the vals types match up with this type
-	for _, v := range p.allowedVals {
+	// check that all the vals types match up with this type, and convert them
+	// to it so they compare equal to what's parsed, e.g. an int for an int64
+	vals := make([]interface{}, len(p.allowedVals))
+	for i, v := range p.allowedVals {
 		vt := reflect.TypeOf(v)
 		if !vt.ConvertibleTo(t) {
 			return fmt.Errorf("All values be convertable to the field type.")
 		}
+		vals[i] = reflect.ValueOf(v).Convert(t).Interface()
 	}
+	p.vals = vals
 
 	// prepare our sub-type if we need to
 	if ps, ok := p.schema.(PreparedSchemaType); ok {
@@ -65,15 +136,109 @@ func (p *EnumParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	}
 
 	// get a reflect.Value of the parsed out value (de-ref ptr if needed)
-	vinf := reflect.Indirect(reflect.ValueOf(v)).Interface()
+	dest := reflect.Indirect(reflect.ValueOf(v))
+	vinf := dest.Interface()
+
+	// named values are compared by number, so any integer type will do
+	if p.names != nil {
+		if n, ok := enumInt(dest); ok {
+			if _, ok := p.names[n]; ok {
+				return nil
+			}
+		}
+		var errs ValidationError
+		return errs.Add(path(), p.invalidMsg(vinf))
+	}
 
 	// check it's one of the accepted values
-	for _, val := range p.allowedVals {
-		if reflect.DeepEqual(val, vinf) {
-			return nil
+	if p.equal != nil {
+		for _, val := range p.allowedVals {
+			if p.equal(val, vinf) {
+				return nil
+			}
+		}
+	} else {
+		for _, val := range p.values() {
+			if reflect.DeepEqual(val, vinf) {
+				return nil
+			}
+		}
+	}
+
+	if p.foldCase && p.equal == nil && dest.Kind() == reflect.String {
+		for _, val := range p.values() {
+			if av := reflect.ValueOf(val); av.Kind() == reflect.String && strings.EqualFold(av.String(), dest.String()) {
+				if dest.CanSet() {
+					dest.Set(av.Convert(dest.Type()))
+				}
+				return nil
+			}
 		}
 	}
 
 	var errs ValidationError
-	return errs.Add(path(), p.invalidMsg)
+	return errs.Add(path(), p.invalidMsg(vinf))
+}
+
+/*
+The allowed values, converted to the field's type if Prepare has been called.
+*/
+func (p *EnumParser) values() []interface{} {
+	if p.vals != nil {
+		return p.vals
+	}
+	return p.allowedVals
+}
+
+/*
+Builds the error message for an invalid value, got. Only done once validation
+has failed as it can be expensive for large enums.
+*/
+func (p *EnumParser) invalidMsg(got interface{}) string {
+	vals := p.values()
+	parts := make([]string, 0, enumMsgMaxVals+1)
+	for i, v := range vals {
+		if i == enumMsgMaxVals {
+			parts = append(parts, fmt.Sprintf("… and %d more", len(vals)-i))
+			break
+		}
+		if p.names != nil {
+			parts = append(parts, p.names[p.allowedVals[i].(int64)])
+		} else {
+			parts = append(parts, enumValString(v))
+		}
+	}
+
+	return fmt.Sprintf(ERROR_ENUM, enumValString(got), strings.Join(parts, ", "))
+}
+
+/*
+Formats an enum value for an error message, as JSON where fmt's formatting
+wouldn't be readable, e.g. for structs.
+*/
+func enumValString(v interface{}) string {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.String:
+		return strconv.Quote(rv.String())
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+/*
+Gets the value of v as an int64, if it's an integer.
+*/
+func enumInt(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := v.Uint(); u <= math.MaxInt64 {
+			return int64(u), true
+		}
+	}
+	return 0, false
 }
@@ -0,0 +1,129 @@
+package jsonv
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+/*
+Parses any JSON number value, including whole numbers, and stores it in a Go
+float32 or float64.
+
+JSON has no way to write NaN or the infinities, but a number too large for the
+destination, e.g. 1e400, overflows to an infinity. By default these are
+rejected, see AllowNonFinite.
+*/
+type FloatParser struct {
+	vs             []FloatValidator
+	bitSize        int
+	allowNonFinite bool
+}
+
+func Float(vs ...FloatValidator) *FloatParser {
+	return &FloatParser{vs: vs, bitSize: 64}
+}
+
+/*
+Report values that aren't finite, e.g. that overflow to +Inf, as invalid. This
+is the default.
+*/
+func (p *FloatParser) RejectNonFinite() *FloatParser {
+	p.allowNonFinite = false
+	return p
+}
+
+/*
+Accept values that overflow to +/-Inf, e.g. for scientific data where that's
+meaningful, rather than reporting them as invalid.
+*/
+func (p *FloatParser) AllowNonFinite() *FloatParser {
+	p.allowNonFinite = true
+	return p
+}
+
+func (p *FloatParser) ExpectedType() JSONType {
+	return JSONNumber
+}
+
+func (p *FloatParser) Prepare(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+	default:
+		return fmt.Errorf(ERROR_PREPARE_DEST, "float", t)
+	}
+
+	p.bitSize = t.Bits()
+	return nil
+}
+
+func (p *FloatParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	f, err := p.parse(path, s)
+	if err != nil {
+		return err
+	}
+
+	switch t := v.(type) {
+	default:
+		// other float types, e.g. a `type Celsius float64`
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return NewParseError(ERROR_BAD_FLOAT_DEST, reflect.TypeOf(v), path())
+		}
+		switch rv = rv.Elem(); rv.Kind() {
+		default:
+			return NewParseError(ERROR_BAD_FLOAT_DEST, reflect.TypeOf(v), path())
+		case reflect.Float32, reflect.Float64:
+			rv.SetFloat(f)
+		}
+	case *float32:
+		*t = float32(f)
+	case *float64:
+		*t = f
+	}
+
+	return nil
+}
+
+func (p *FloatParser) Validate(path Pather, s *Scanner) error {
+	_, err := p.parse(path, s)
+	return err
+}
+
+/*
+Reads and validates a float.
+*/
+func (p *FloatParser) parse(path Pather, s *Scanner) (float64, error) {
+	tok, buf, err := s.ReadToken()
+	if tok == TokenError {
+		return 0, err
+	} else if tok != TokenNumber {
+		return 0, wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_FLOAT, string(buf)))
+	}
+
+	var errs ValidationError
+
+	f, err := strconv.ParseFloat(string(buf), p.bitSize)
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		// overflow is reported as a range error along with the Inf
+		if !p.allowNonFinite {
+			errs = errs.Add(path(), ERROR_FLOAT_NON_FINITE)
+			return 0, errs
+		}
+	} else if err != nil {
+		errs = errs.Add(path(), err.Error())
+		return 0, errs
+	}
+
+	// check the value
+	for _, v := range p.vs {
+		if err := v.ValidateFloat(f); err != nil {
+			errs = errs.Add(path(), err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return 0, errs
+	}
+	return f, nil
+}
@@ -1,7 +1,9 @@
 package jsonv
 
 import (
+	"bytes"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 )
@@ -10,24 +12,61 @@ import (
 Parses any whole-integer JSON number value and stores it in any Go integer
 primitive type, e.g. int8, int16, uint8, etc.
 
-It can only parse values that are within the int64 range, even when stored into
-a uint64 variable.
+Unsigned destinations, e.g. uint64, take their whole range, and reject negative
+values. As validators only see int64s, values above math.MaxInt64 are given to
+them as math.MaxInt64.
 */
 type IntegerParser struct {
-	vs      []IntegerValidator
-	bitSize int
+	vs          []IntegerValidator
+	bitSize     int
+	unsigned    bool
+	emptyAbsent bool
+	wholeFloat  bool
 }
 
+/*
+The largest magnitude whole number a float64 can hold where every smaller
+whole number can also be held exactly, i.e. 2^53 - 1.
+*/
+const maxExactFloatInt = 1<<53 - 1
+
 func Integer(vs ...IntegerValidator) *IntegerParser {
-	return &IntegerParser{vs, 64}
+	return &IntegerParser{vs: vs, bitSize: 64}
+}
+
+/*
+Treat an empty string, i.e. "", as though the value were absent rather than
+invalid. See ErrAbsent.
+*/
+func (p *IntegerParser) EmptyAsAbsent() *IntegerParser {
+	p.emptyAbsent = true
+	return p
+}
+
+/*
+Accept numbers written with a fraction or exponent, e.g. 24.0 or 2.4e1, as long
+as they're whole numbers, as produced by many float-only clients.
+
+To guard against silent precision loss, such numbers must also be within the
+range that a float64 can represent exactly, i.e. +/-(2^53 - 1).
+*/
+func (p *IntegerParser) AllowWholeFloat() *IntegerParser {
+	p.wholeFloat = true
+	return p
+}
+
+func (p *IntegerParser) ExpectedType() JSONType {
+	return JSONNumber
 }
 
 func (p *IntegerParser) Prepare(t reflect.Type) error {
 	switch t.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.unsigned = false
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		p.unsigned = true
 	default:
-		return fmt.Errorf("Want an integer type not %v", t)
+		return fmt.Errorf(ERROR_PREPARE_DEST, "integer", t)
 	}
 
 	p.bitSize = t.Bits()
@@ -35,43 +74,35 @@ func (p *IntegerParser) Prepare(t reflect.Type) error {
 }
 
 func (p *IntegerParser) Parse(path Pather, s *Scanner, v interface{}) error {
-	tok, buf, err := s.ReadToken()
-	if tok == TokenError {
-		return err
-	} else if tok != TokenNumber {
-		return NewParseError(ERROR_INVALID_INT, string(buf))
-	}
-
-	var errs ValidationError
-
-	tv, err := strconv.ParseInt(string(buf), 10, p.bitSize)
+	tv, err := p.parse(path, s)
 	if err != nil {
-		errs = errs.Add(path(), err.Error())
-		return errs
-	}
-
-	// check the value
-	for _, v := range p.vs {
-		if err := v.ValidateInteger(tv); err != nil {
-			errs = errs.Add(path(), err.Error())
-		}
-	}
-
-	// bail before setting if validation failed
-	if len(errs) > 0 {
-		return errs
+		return err
 	}
 
 	// now assign the value with whatever precision we can
 	switch t := v.(type) {
 	default:
-		return NewParseError(ERROR_BAD_INT_DEST, reflect.TypeOf(v))
+		// other integer types, e.g. a `type Color int`
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return NewParseError(ERROR_BAD_INT_DEST, reflect.TypeOf(v), path())
+		}
+		switch rv = rv.Elem(); rv.Kind() {
+		default:
+			return NewParseError(ERROR_BAD_INT_DEST, reflect.TypeOf(v), path())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			rv.SetInt(tv)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			rv.SetUint(uint64(tv))
+		}
 	case *int:
 		*t = int(tv)
 	case *int8:
 		*t = int8(tv)
 	case *int16:
 		*t = int16(tv)
+	case *int32:
+		*t = int32(tv)
 	case *int64:
 		*t = tv
 	case *uint:
@@ -80,9 +111,103 @@ func (p *IntegerParser) Parse(path Pather, s *Scanner, v interface{}) error {
 		*t = uint8(tv)
 	case *uint16:
 		*t = uint16(tv)
+	case *uint32:
+		*t = uint32(tv)
 	case *uint64:
 		*t = uint64(tv)
 	}
 
 	return nil
 }
+
+func (p *IntegerParser) Validate(path Pather, s *Scanner) error {
+	_, err := p.parse(path, s)
+	return err
+}
+
+/*
+Reads and validates an integer. For unsigned destinations, the result is the
+bits of the uint64 value, which must be converted back with uint64().
+*/
+func (p *IntegerParser) parse(path Pather, s *Scanner) (int64, error) {
+	tok, buf, err := s.ReadToken()
+	if tok == TokenError {
+		return 0, err
+	} else if p.emptyAbsent && tok == TokenString && len(buf) == 2 {
+		return 0, ErrAbsent
+	} else if tok != TokenNumber {
+		return 0, wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_INT, string(buf)))
+	}
+
+	var errs ValidationError
+
+	var tv int64
+	if p.wholeFloat && bytes.ContainsAny(buf, ".eE") {
+		tv, err = parseWholeFloat(buf, p.bitSize, p.unsigned)
+	} else {
+		tv, err = parseInt(string(buf), p.bitSize, p.unsigned)
+	}
+	if err != nil {
+		errs = errs.Add(path(), err.Error())
+		return 0, errs
+	}
+
+	// check the value, which validators can only see as an int64
+	vv := tv
+	if p.unsigned && vv < 0 {
+		vv = math.MaxInt64
+	}
+	for _, v := range p.vs {
+		if err := v.ValidateInteger(vv); err != nil {
+			errs = errs.Add(path(), err.Error())
+		}
+	}
+
+	// bail before setting if validation failed
+	if len(errs) > 0 {
+		return 0, errs
+	}
+	return tv, nil
+}
+
+/*
+Parses an integer that must fit in an int, or uint if unsigned, of bitSize bits.
+Unsigned values are returned as the bits of their uint64.
+*/
+func parseInt(str string, bitSize int, unsigned bool) (int64, error) {
+	if !unsigned {
+		return strconv.ParseInt(str, 10, bitSize)
+	} else if str == "-0" {
+		return 0, nil
+	} else if len(str) > 0 && str[0] == '-' {
+		return 0, fmt.Errorf(ERROR_INT_NEGATIVE)
+	}
+
+	u, err := strconv.ParseUint(str, 10, bitSize)
+	return int64(u), err
+}
+
+/*
+Parses a float formatted number into an int64, but only if it's a whole number
+that can be represented exactly by both a float64 and an int, or uint if
+unsigned, of bitSize bits.
+*/
+func parseWholeFloat(buf []byte, bitSize int, unsigned bool) (int64, error) {
+	f, err := strconv.ParseFloat(string(buf), 64)
+	if err != nil {
+		return 0, err
+	} else if f != math.Trunc(f) {
+		return 0, fmt.Errorf(ERROR_INT_NOT_WHOLE)
+	} else if math.Abs(f) > maxExactFloatInt {
+		return 0, fmt.Errorf(ERROR_INT_PRECISION, int64(maxExactFloatInt))
+	}
+
+	// make sure the conversion round trips
+	i := int64(f)
+	if float64(i) != f {
+		return 0, fmt.Errorf(ERROR_INT_PRECISION, int64(maxExactFloatInt))
+	}
+
+	// and that it fits in the destination
+	return parseInt(strconv.FormatInt(i, 10), bitSize, unsigned)
+}
@@ -0,0 +1,219 @@
+package jsonv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+Parses a JSON object into a Go map with string keys, e.g. map[string]int64,
+parsing every property's value with the same schema.
+
+Each key is checked with any key validators before its value is parsed, e.g.
+to only allow lowercase slugs:
+
+	Map(Integer(), Pattern("^[a-z0-9-]+$", "slug"))
+
+Invalid keys are reported at their own path and their values skipped, so the
+valid properties are still parsed. Properties are added to any existing map,
+and if a key appears more than once, the last value is kept.
+
+See Entries to limit the number of properties.
+*/
+type MapParser struct {
+	schema   SchemaType
+	keyVs    []StringValidator
+	vs       []CountValidator // see Entries
+	keyType  reflect.Type
+	elemType reflect.Type
+}
+
+func Map(valueSchema SchemaType, keyVs ...StringValidator) *MapParser {
+	return &MapParser{schema: valueSchema, keyVs: keyVs}
+}
+
+/*
+Checks the number of properties in the object with count validators, e.g.
+Entries(MinItems(1), MaxItems(20)). Every property counts, including invalid
+and repeated keys, and errors are reported at the object's path.
+*/
+func (p *MapParser) Entries(vs ...CountValidator) *MapParser {
+	p.vs = append(p.vs, vs...)
+	return p
+}
+
+func (p *MapParser) ExpectedType() JSONType {
+	return JSONObject
+}
+
+func (p *MapParser) Prepare(t reflect.Type) error {
+	if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+		return fmt.Errorf(ERROR_BAD_MAP_DEST, t)
+	}
+
+	p.keyType = t.Key()
+	p.elemType = t.Elem()
+
+	// prepare our sub-type if we need to
+	if ps, ok := p.schema.(PreparedSchemaType); ok {
+		return ps.Prepare(p.elemType)
+	}
+
+	return nil
+}
+
+func (p *MapParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	ptrVal := reflect.ValueOf(v)
+	if ptrVal.Kind() != reflect.Ptr || ptrVal.IsNil() || ptrVal.Elem().Kind() != reflect.Map {
+		return fmt.Errorf(ERROR_BAD_MAP_DEST, ptrVal.Type())
+	}
+
+	val := ptrVal.Elem()
+	if val.IsNil() {
+		val.Set(reflect.MakeMap(val.Type()))
+	}
+	return p.parse(path, s, val)
+}
+
+func (p *MapParser) Validate(path Pather, s *Scanner) error {
+	return p.parse(path, s, reflect.Value{})
+}
+
+/*
+Parses the object into val, or, if val is the zero Value, just validates it.
+*/
+func (p *MapParser) parse(path Pather, s *Scanner, val reflect.Value) error {
+	// re-used for each value
+	var elem reflect.Value
+	if val.IsValid() {
+		elem = reflect.New(p.elemType)
+	}
+
+	n := 0
+	err := forEachPair(path, s, func(keyPath Pather, key string) error {
+		n++
+
+		// check the key
+		var errs ValidationError
+		for _, v := range p.keyVs {
+			if err := v.ValidateString(key); err != nil {
+				errs = errs.Add(keyPath(), err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			if err := s.SkipValue(); err != nil {
+				return err
+			}
+			return errs
+		}
+
+		// and its value
+		if !elem.IsValid() {
+			return validateValue(p.schema, p.elemType, keyPath, s)
+		}
+		elem.Elem().Set(reflect.Zero(p.elemType))
+		if err := p.schema.Parse(keyPath, s, elem.Interface()); err != nil {
+			return err
+		}
+		val.SetMapIndex(reflect.ValueOf(key).Convert(p.keyType), elem.Elem())
+		return nil
+	})
+	if len(p.vs) == 0 {
+		return err
+	}
+
+	// check the number of entries
+	errs, ok := err.(ValidationError)
+	if err != nil && !ok {
+		return err
+	}
+	for _, v := range p.vs {
+		if err := v.ValidateCount(n); err != nil {
+			errs = errs.Add(path(), err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+/*
+Reads an object, calling fn to read each property's value. Any ValidationError
+fn returns is collected, and returned once the whole object has been read, any
+other error stops the parse.
+
+The object's keys are limited by Scanner.MaxObjectKeys.
+*/
+func forEachPair(path Pather, s *Scanner, fn func(keyPath Pather, key string) error) error {
+	// read the '{'
+	tok, _, err := s.ReadToken()
+	if tok == TokenError {
+		return err
+	} else if tok != TokenObjectBegin {
+		return NewParseError("Expected '{' not %v", tok)
+	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+
+	var errs ValidationError
+	var key string
+	keyPath := func() string {
+		return path() + key
+	}
+
+	for n := 0; ; n++ {
+		// read the key, or '}'
+		tok, keyb, err := s.ReadKey()
+		if tok == TokenError {
+			return err
+		} else if tok == TokenObjectEnd {
+			if n > 0 && !s.allowTrailingComma {
+				return NewParseError(ERROR_TRAILING_COMMA_OBJ)
+			}
+			break
+		} else if tok != TokenString {
+			return NewParseError("Expected object property name or '}' not %v", tok)
+		} else if s.maxObjectKeys > 0 && n >= s.maxObjectKeys {
+			return NewParseError(ERROR_TOO_MANY_KEYS, s.maxObjectKeys, s.rcount-len(keyb))
+		} else if err := s.element(n, len(keyb)); err != nil {
+			return err
+		}
+		key, _ = Unquote(keyb)
+
+		// read the ':'
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return err
+		} else if tok != TokenPropSep {
+			return NewParseError("Expected ':' not %v", tok)
+		}
+
+		err = fn(keyPath, key)
+		if err == ErrAbsent {
+			err = NewSingleVErr(keyPath(), ERROR_PROP_REQUIRED)
+		}
+		if verr, ok := err.(ValidationError); ok {
+			if errs, err = s.addErrors(errs, verr); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		// we want a , or a }
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return err
+		} else if tok == TokenObjectEnd {
+			break
+		} else if tok != TokenItemSep {
+			return NewParseError("Expected ',' or '}' not %v", tok)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package jsonv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+Parses a JSON null, and nothing else, e.g. for tri-state APIs where a prop
+being null means something different to it being absent.
+
+Null carries no value so the destination is left untouched, unless it's a bool,
+which is set to true to record that the null was there.
+*/
+type NullParser struct{}
+
+func Null() *NullParser {
+	return &NullParser{}
+}
+
+func (p *NullParser) ExpectedType() JSONType {
+	return JSONNull
+}
+
+/*
+Any destination type is fine, see NullParser.
+*/
+func (p *NullParser) Prepare(t reflect.Type) error {
+	return nil
+}
+
+func (p *NullParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	if err := p.Validate(path, s); err != nil {
+		return err
+	}
+
+	if t, ok := v.(*bool); ok {
+		*t = true
+	}
+	return nil
+}
+
+func (p *NullParser) Validate(path Pather, s *Scanner) error {
+	tok, buf, err := s.ReadToken()
+	if tok == TokenError {
+		return err
+	} else if tok != TokenNull {
+		return wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_NULL, string(buf)))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package jsonv
+
+import (
+	"reflect"
+)
+
+/*
+Accepts JSON null as well as whatever schema accepts, so null can map to a Go
+nil pointer with any type, e.g. {"Age": null} for an *int64 field.
+
+For a pointer destination, e.g. an element of a []*int64, null sets it to nil,
+otherwise the pointer is allocated if needed and schema parses into what it
+points to. Struct props with pointer fields are allocated by the Struct, so for
+those, and non-pointer destinations, null is treated as though the prop were
+absent (see ErrAbsent), leaving a pointer field nil.
+*/
+type NullableParser struct {
+	schema   SchemaType
+	ptr      bool         // whether the destination is a pointer
+	elemType reflect.Type // the type schema parses into
+}
+
+func Nullable(s SchemaType) *NullableParser {
+	return &NullableParser{schema: s}
+}
+
+func (p *NullableParser) ExpectedType() JSONType {
+	return ExpectedTypeOf(p.schema)
+}
+
+func (p *NullableParser) Prepare(t reflect.Type) error {
+	p.ptr = t.Kind() == reflect.Ptr
+	if p.ptr {
+		t = t.Elem()
+	}
+	p.elemType = t
+
+	if ps, ok := p.schema.(PreparedSchemaType); ok {
+		return ps.Prepare(t)
+	}
+	return nil
+}
+
+func (p *NullableParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	null, err := p.readNull(s)
+	if err != nil {
+		return err
+	} else if !p.ptr {
+		if null {
+			return ErrAbsent
+		}
+		return p.schema.Parse(path, s, v)
+	}
+
+	ptrVal := reflect.ValueOf(v)
+	if ptrVal.Kind() != reflect.Ptr || ptrVal.IsNil() || ptrVal.Elem().Kind() != reflect.Ptr {
+		return NewParseError(ERROR_BAD_NULLABLE_DEST, reflect.TypeOf(v), path())
+	}
+	val := ptrVal.Elem()
+
+	if null {
+		val.Set(reflect.Zero(val.Type()))
+		return nil
+	}
+	if val.IsNil() {
+		val.Set(reflect.New(p.elemType))
+	}
+	return p.schema.Parse(path, s, val.Interface())
+}
+
+func (p *NullableParser) Validate(path Pather, s *Scanner) error {
+	if null, err := p.readNull(s); err != nil || null {
+		return err
+	}
+	return validateValue(p.schema, p.elemType, path, s)
+}
+
+/*
+Reads the next value if it's a null, returning whether it was.
+*/
+func (p *NullableParser) readNull(s *Scanner) (bool, error) {
+	if tok, err := s.PeekToken(); tok == TokenError {
+		return false, err
+	} else if tok != TokenNull {
+		return false, nil
+	}
+
+	if _, _, err := s.ReadToken(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,162 @@
+package jsonv
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/*
+One of the shapes a OneOf can parse, the schema to parse it with and the type
+to parse it into, given as an example value, e.g. Case(&Click{}, clickSchema).
+If the example is a pointer, a pointer to the new value is stored.
+*/
+type OneOfCase struct {
+	schema SchemaType
+	typ    reflect.Type
+}
+
+func Case(example interface{}, schema SchemaType) OneOfCase {
+	return OneOfCase{schema: schema, typ: reflect.TypeOf(example)}
+}
+
+/*
+Parses an object whose shape depends on one of its props, the discriminator,
+into an interface destination, using the case registered for the
+discriminator's value, e.g. for a mix of events:
+
+	schema := Slice(OneOf("type", map[string]OneOfCase{
+		"click": Case(&Click{}, Struct(Prop("X", Integer()), Prop("Y", Integer()))),
+		"key":   Case(&KeyPress{}, Struct(Prop("Key", String()))),
+	}))
+
+	var events []interface{}
+
+The destination can be any interface type that all of the case types
+implement. The discriminator can appear anywhere in the object, so each object
+is read into memory before it's parsed. Case schemas don't need a prop for
+the discriminator, it's skipped like any other unknown prop.
+
+Errors from a case are reported at the object's own path, so within a Slice
+they include the item's index, e.g. "/3/X". A missing discriminator is reported
+at the object's path, and one with an unknown value at its own, e.g.
+"/3/type".
+*/
+type OneOfParser struct {
+	key   string
+	cases map[string]OneOfCase
+	names string // the discriminator's allowed values, for error messages
+}
+
+func OneOf(key string, cases map[string]OneOfCase) *OneOfParser {
+	names := make([]string, 0, len(cases))
+	for name := range cases {
+		names = append(names, enumValString(name))
+	}
+	sort.Strings(names)
+
+	return &OneOfParser{key: key, cases: cases, names: strings.Join(names, ", ")}
+}
+
+func (p *OneOfParser) ExpectedType() JSONType {
+	return JSONObject
+}
+
+func (p *OneOfParser) Prepare(t reflect.Type) error {
+	if t.Kind() != reflect.Interface {
+		return NewSchemaConfigError(ERROR_PREPARE_DEST, "interface", t)
+	}
+
+	for name, c := range p.cases {
+		if c.typ == nil || !c.typ.AssignableTo(t) {
+			return NewSchemaConfigError(ERROR_PREPARE_CASE, name, c.typ, t)
+		}
+		if err := prepareSchema(c.valueType(), c.schema); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *OneOfParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	ptrVal := reflect.ValueOf(v)
+	if ptrVal.Kind() != reflect.Ptr || ptrVal.IsNil() || ptrVal.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf(ERROR_BAD_ONE_OF_DEST, ptrVal.Type())
+	}
+
+	c, ns, err := p.choose(path, s)
+	if err != nil {
+		return err
+	}
+
+	val := reflect.New(c.valueType())
+	if err := c.schema.Parse(path, ns, val.Interface()); err != nil {
+		return err
+	}
+
+	if c.typ.Kind() == reflect.Ptr {
+		ptrVal.Elem().Set(val)
+	} else {
+		ptrVal.Elem().Set(val.Elem())
+	}
+	return nil
+}
+
+func (p *OneOfParser) Validate(path Pather, s *Scanner) error {
+	c, ns, err := p.choose(path, s)
+	if err != nil {
+		return err
+	}
+	return validateValue(c.schema, c.valueType(), path, ns)
+}
+
+/*
+Reads the next object from s, returning the case for its discriminator and a
+Scanner to parse the object from.
+*/
+func (p *OneOfParser) choose(path Pather, s *Scanner) (OneOfCase, *Scanner, error) {
+	buf, err := s.appendValue(nil)
+	if err != nil {
+		return OneOfCase{}, nil, err
+	}
+
+	// it's well-formed, so the only errors left are validation errors
+	invalid := func(msg string) (OneOfCase, *Scanner, error) {
+		return OneOfCase{}, nil, NewSingleVErr(path(), msg)
+	}
+	invalidKey := func(msg string) (OneOfCase, *Scanner, error) {
+		return OneOfCase{}, nil, NewSingleVErr(path()+p.key, msg)
+	}
+
+	ds := NewBytesScanner(buf)
+	if tok, _, _ := ds.ReadToken(); tok != TokenObjectBegin {
+		return invalid(fmt.Sprintf(ERROR_ONE_OF_KEY, p.key))
+	}
+	if found, err := ds.SkipToKey(p.key); err != nil || !found {
+		return invalid(fmt.Sprintf(ERROR_ONE_OF_KEY, p.key))
+	}
+	tok, valb, _ := ds.ReadToken()
+	if tok != TokenString {
+		return invalidKey(fmt.Sprintf(ERROR_ONE_OF_KEY, p.key))
+	}
+
+	name, _ := Unquote(valb)
+	c, ok := p.cases[name]
+	if !ok {
+		return invalidKey(fmt.Sprintf(ERROR_ENUM, enumValString(name), p.names))
+	}
+
+	return c, s.subScanner(buf), nil
+}
+
+/*
+The type the case's schema parses into.
+*/
+func (c OneOfCase) valueType() reflect.Type {
+	if c.typ.Kind() == reflect.Ptr {
+		return c.typ.Elem()
+	}
+	return c.typ
+}
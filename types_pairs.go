@@ -0,0 +1,94 @@
+package jsonv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+Parses a JSON object into a slice of key/value structs, one per property, in
+the order they appear in the input and including any duplicate keys, both of
+which are lost when parsing into a map.
+
+The slice's element type must be a struct with two exported fields, the key,
+which must be a string, then the value, which is parsed with valueSchema, e.g.
+
+	type Header struct {
+		Name  string
+		Value string
+	}
+
+	schema := Pairs(String()) // for a []Header
+
+As with Map, the values' errors are reported at their key's path.
+*/
+type PairsParser struct {
+	schema   SchemaType
+	elemType reflect.Type
+}
+
+func Pairs(valueSchema SchemaType) *PairsParser {
+	return &PairsParser{schema: valueSchema}
+}
+
+func (p *PairsParser) ExpectedType() JSONType {
+	return JSONObject
+}
+
+func (p *PairsParser) Prepare(t reflect.Type) error {
+	if t.Kind() != reflect.Slice {
+		return fmt.Errorf(ERROR_BAD_PAIRS_DEST, t)
+	}
+
+	et := t.Elem()
+	if et.Kind() != reflect.Struct || et.NumField() != 2 {
+		return fmt.Errorf(ERROR_BAD_PAIRS_DEST, t)
+	}
+	for i := 0; i < 2; i++ {
+		if et.Field(i).PkgPath != "" {
+			return fmt.Errorf(ERROR_BAD_PAIRS_DEST, t)
+		}
+	}
+	if et.Field(0).Type.Kind() != reflect.String {
+		return fmt.Errorf(ERROR_BAD_PAIRS_DEST, t)
+	}
+
+	p.elemType = et
+
+	// prepare our sub-type if we need to
+	if ps, ok := p.schema.(PreparedSchemaType); ok {
+		return ps.Prepare(et.Field(1).Type)
+	}
+
+	return nil
+}
+
+func (p *PairsParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	ptrVal := reflect.ValueOf(v)
+	if ptrVal.Kind() != reflect.Ptr || ptrVal.IsNil() || ptrVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf(ERROR_BAD_PAIRS_DEST, ptrVal.Type())
+	}
+
+	// re-use the slice's storage
+	val := ptrVal.Elem()
+	val.SetLen(0)
+
+	elem := reflect.New(p.elemType).Elem()
+	zero := reflect.Zero(p.elemType)
+	return forEachPair(path, s, func(keyPath Pather, key string) error {
+		elem.Set(zero)
+		elem.Field(0).SetString(key)
+		if err := p.schema.Parse(keyPath, s, elem.Field(1).Addr().Interface()); err != nil {
+			return err
+		}
+		val.Set(reflect.Append(val, elem))
+		return nil
+	})
+}
+
+func (p *PairsParser) Validate(path Pather, s *Scanner) error {
+	valueType := p.elemType.Field(1).Type
+	return forEachPair(path, s, func(keyPath Pather, key string) error {
+		return validateValue(p.schema, valueType, keyPath, s)
+	})
+}
@@ -0,0 +1,49 @@
+package jsonv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+Accepts any JSON value and stores its JSON, exactly as it is in the input, in a
+[]byte or json.RawMessage, e.g. for a polymorphic payload that's only parsed
+once its type is known.
+
+The value must be well-formed JSON, but isn't validated any further.
+*/
+type RawJSONParser struct{}
+
+func RawJSON() *RawJSONParser {
+	return &RawJSONParser{}
+}
+
+func (p *RawJSONParser) ExpectedType() JSONType {
+	return JSONAny
+}
+
+func (p *RawJSONParser) Prepare(t reflect.Type) error {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+		return fmt.Errorf(ERROR_PREPARE_DEST, "[]byte", t)
+	}
+
+	return nil
+}
+
+func (p *RawJSONParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	ptrVal := reflect.ValueOf(v)
+	if ptrVal.Kind() != reflect.Ptr || ptrVal.IsNil() || ptrVal.Elem().Kind() != reflect.Slice || ptrVal.Elem().Type().Elem().Kind() != reflect.Uint8 {
+		return fmt.Errorf(ERROR_BAD_BYTE_DEST, reflect.TypeOf(v), path())
+	}
+
+	raw, err := s.ReadRawValue()
+	if err != nil {
+		return err
+	}
+	ptrVal.Elem().SetBytes(raw)
+	return nil
+}
+
+func (p *RawJSONParser) Validate(path Pather, s *Scanner) error {
+	return s.SkipValue()
+}
@@ -3,24 +3,40 @@ package jsonv
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 )
 
 /*
 Parses a JSON value into an array whos values are a single type.
+
+The destination can also be a fixed-length Go array, e.g. [3]int64 for an RGB
+triple, in which case the JSON array must have exactly that many items.
+Validators are then given a slice of the items that were stored.
 */
 type SliceParser struct {
 	elemType reflect.Type
 	schema   SchemaType
 	vs       []SliceValidator
+	array    bool // whether the destination is an array of arrayLen items
+	arrayLen int
 }
 
 func Slice(s SchemaType, vs ...SliceValidator) *SliceParser {
 	return &SliceParser{schema: s, vs: vs}
 }
 
+func (p *SliceParser) ExpectedType() JSONType {
+	return JSONArray
+}
+
 func (p *SliceParser) Prepare(t reflect.Type) error {
-	// make sure it's a struct
-	if t.Kind() != reflect.Slice {
+	// make sure it's a slice or array
+	switch t.Kind() {
+	case reflect.Slice:
+		p.array, p.arrayLen = false, 0
+	case reflect.Array:
+		p.array, p.arrayLen = true, t.Len()
+	default:
 		return fmt.Errorf(ERROR_BAD_SLICE_DEST, t)
 	}
 
@@ -42,11 +58,30 @@ func (p *SliceParser) Parse(path Pather, s *Scanner, v interface{}) error {
 		return fmt.Errorf(ERROR_BAD_SLICE_DEST, ptrVal.Type())
 	}
 	val := ptrVal.Elem()
-	valType := val.Type()
-	if valType.Kind() != reflect.Slice {
+	if k := val.Kind(); k != reflect.Slice && k != reflect.Array {
 		return fmt.Errorf(ERROR_BAD_SLICE_DEST, ptrVal.Type())
 	}
+	return p.parse(path, s, val)
+}
+
+/*
+Checks the array's elements without storing them. Slice validators other than
+count validators, e.g. MinItems, need the elements, so if there are any the
+array is parsed into a temporary slice instead.
+*/
+func (p *SliceParser) Validate(path Pather, s *Scanner) error {
+	for _, v := range p.vs {
+		if _, ok := v.(CountValidator); !ok {
+			return p.parse(path, s, reflect.New(reflect.SliceOf(p.elemType)).Elem())
+		}
+	}
+	return p.parse(path, s, reflect.Value{})
+}
 
+/*
+Parses the array into val, or, if val is the zero Value, just validates it.
+*/
+func (p *SliceParser) parse(path Pather, s *Scanner, val reflect.Value) error {
 	// read the '['
 	tok, _, err := s.ReadToken()
 	if tok == TokenError {
@@ -54,10 +89,12 @@ func (p *SliceParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	} else if tok != TokenArrayBegin {
 		return NewParseError("Expected '[' not " + tok.String())
 	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
 
-	finished := false
-
-	// see if we have at least 1 value
+	// a fast path for [], which is common, with nothing to set up
 	if tok, err := s.PeekToken(); err != nil {
 		return err
 	} else if tok == TokenArrayEnd {
@@ -65,41 +102,57 @@ func (p *SliceParser) Parse(path Pather, s *Scanner, v interface{}) error {
 		if _, _, err := s.ReadToken(); err != nil {
 			return err
 		}
-		finished = true
+		if val.IsValid() && val.Kind() == reflect.Array {
+			val.Set(reflect.Zero(val.Type()))
+		} else if val.IsValid() {
+			val.SetLen(0)
+		}
+		return p.finish(path, val, 0, nil)
 	}
+	finished := false
 
 	// this is where we'll store all the validation errors
 	var errs ValidationError
 
+	// get the funcs to add elements and finish up the slice, avoiding reflect
+	// if we can
+	var next func() interface{}
+	var done func()
+	if !val.IsValid() {
+		next, done = func() interface{} { return nil }, func() {}
+	} else if val.Kind() == reflect.Array {
+		next, done = arrayElems(val)
+	} else if next, done = scalarSlice(val.Addr().Interface()); next == nil {
+		next, done = reflectSlice(val)
+	}
+
 	// now read val then ','|']'
 	i := 0
 	itemPath := func() string {
-		return fmt.Sprintf("%s%d/", path(), i)
+		return path() + strconv.Itoa(i) + "/"
 	}
 	for !finished {
-		// next up must be a value
-		// Grow the slice if necessary
-		if i >= val.Cap() {
-			newcap := val.Cap() + val.Cap()/2
-			if newcap < 4 {
-				newcap = 4
-			}
-			newv := reflect.MakeSlice(val.Type(), val.Len(), newcap)
-			reflect.Copy(newv, val)
-			val.Set(newv)
-		}
-		if i >= val.Len() {
-			val.SetLen(i + 1)
+		if err := s.element(i, 0); err != nil {
+			return err
 		}
 
 		// read in the value
-		itemPtr := val.Index(i).Addr().Interface()
-		if err := p.schema.Parse(itemPath, s, itemPtr); err != nil {
-			if verr, ok := err.(ValidationError); ok {
-				errs = errs.AddMany(verr)
-			} else {
+		var err error
+		if itemPtr := next(); itemPtr != nil {
+			err = p.schema.Parse(itemPath, s, itemPtr)
+		} else {
+			err = validateValue(p.schema, p.elemType, itemPath, s)
+		}
+		if err == ErrAbsent {
+			// array elements can't be absent
+			err = NewSingleVErr(itemPath(), ERROR_PROP_REQUIRED)
+		}
+		if verr, ok := err.(ValidationError); ok {
+			if errs, err = s.addErrors(errs, verr); err != nil {
 				return err
 			}
+		} else if err != nil {
+			return err
 		}
 
 		i++
@@ -110,15 +163,55 @@ func (p *SliceParser) Parse(path Pather, s *Scanner, v interface{}) error {
 		} else if tok == TokenArrayEnd {
 			finished = true
 		} else if tok == TokenItemSep {
+			if tok, err := s.PeekToken(); err != nil {
+				return err
+			} else if tok == TokenArrayEnd {
+				if !s.allowTrailingComma {
+					return NewParseError(ERROR_TRAILING_COMMA_ARR)
+				}
+				// actually consume it
+				if _, _, err := s.ReadToken(); err != nil {
+					return err
+				}
+				finished = true
+			}
 			continue
 		} else {
-			return NewParseError("Expected ',' or '[' not " + tok.String())
+			return NewParseError("After element %d, expected ',' or ']' not %v", i-1, tok)
+		}
+	}
+
+	done()
+	return p.finish(path, val, i, errs)
+}
+
+/*
+Validates the slice once all n of its elements have been read into val, adding
+to the elements' errs. If val is the zero Value, the validators are all count
+validators, see Validate.
+*/
+func (p *SliceParser) finish(path Pather, val reflect.Value, n int, errs ValidationError) error {
+	if p.array && n != p.arrayLen {
+		errs = errs.Add(path(), fmt.Sprintf(ERROR_ARRAY_LEN, p.arrayLen))
+	}
+
+	if val.IsValid() && val.Kind() == reflect.Array {
+		// only the items that were stored
+		if n > val.Len() {
+			n = val.Len()
 		}
+		val = val.Slice(0, n)
 	}
 
 	// validate the contents
 	for _, v := range p.vs {
-		if err := v.ValidateSlice(val); err != nil {
+		var err error
+		if val.IsValid() {
+			err = v.ValidateSlice(val)
+		} else {
+			err = v.(CountValidator).ValidateCount(n)
+		}
+		if err != nil {
 			errs = errs.Add(path(), err.Error())
 		}
 	}
@@ -128,3 +221,116 @@ func (p *SliceParser) Parse(path Pather, s *Scanner, v interface{}) error {
 		return nil
 	}
 }
+
+/*
+Builds the funcs used by SliceParser to add a new element to the end of the
+slice val, returning a pointer to it, and to finish up the slice once all the
+elements have been read.
+
+The slice's existing storage is re-used where possible.
+*/
+func reflectSlice(val reflect.Value) (func() interface{}, func()) {
+	n := 0
+	next := func() interface{} {
+		// Grow the slice if necessary
+		if n >= val.Cap() {
+			newcap := val.Cap() + val.Cap()/2
+			if newcap < 4 {
+				newcap = 4
+			}
+			newv := reflect.MakeSlice(val.Type(), val.Len(), newcap)
+			reflect.Copy(newv, val)
+			val.Set(newv)
+		}
+		if n >= val.Len() {
+			val.SetLen(n + 1)
+		}
+
+		n++
+		return val.Index(n - 1).Addr().Interface()
+	}
+	done := func() {
+		val.SetLen(n)
+	}
+
+	return next, done
+}
+
+/*
+As reflectSlice, but for the fixed-length array val. Once it's full, nil is
+returned, so any more elements are only validated. Any elements left over once
+all have been read are zeroed.
+*/
+func arrayElems(val reflect.Value) (func() interface{}, func()) {
+	n := 0
+	next := func() interface{} {
+		if n >= val.Len() {
+			return nil
+		}
+		n++
+		return val.Index(n - 1).Addr().Interface()
+	}
+	done := func() {
+		zero := reflect.Zero(val.Type().Elem())
+		for i := n; i < val.Len(); i++ {
+			val.Index(i).Set(zero)
+		}
+	}
+
+	return next, done
+}
+
+/*
+Same as reflectSlice, but for slices of scalar types it uses a native slice and
+append, which avoids the per-element cost of reflect.
+
+Returns nil funcs if v isn't a pointer to a slice of a supported type.
+*/
+func scalarSlice(v interface{}) (func() interface{}, func()) {
+	switch dest := v.(type) {
+	case *[]int:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]int8:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]int16:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]int32:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]int64:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]uint:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]uint8:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]uint16:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]uint32:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]uint64:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]float32:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]float64:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, 0); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]bool:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, false); return &d[len(d)-1] }, func() { *dest = d }
+	case *[]string:
+		d := (*dest)[:0]
+		return func() interface{} { d = append(d, ""); return &d[len(d)-1] }, func() { *dest = d }
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,96 @@
+package jsonv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+Parses either a JSON array, or a single value, into a slice, for APIs that only
+send an array when there is more than one item, e.g. both {"id":1} and
+[{"id":1},{"id":2}] for the same field.
+
+Arrays are parsed exactly as by Slice. A single value is parsed with the
+element schema into a slice of length 1, with any errors at the value's own
+path rather than that of an array element. The slice validators are applied in
+both cases.
+*/
+type OneOrManyParser struct {
+	SliceParser
+}
+
+func OneOrMany(s SchemaType, vs ...SliceValidator) *OneOrManyParser {
+	return &OneOrManyParser{SliceParser{schema: s, vs: vs}}
+}
+
+/*
+Unlike Slice, fixed-length arrays aren't supported, as a single value would
+rarely fill one.
+*/
+func (p *OneOrManyParser) Prepare(t reflect.Type) error {
+	if t.Kind() != reflect.Slice {
+		return fmt.Errorf(ERROR_BAD_SLICE_DEST, t)
+	}
+	return p.SliceParser.Prepare(t)
+}
+
+func (p *OneOrManyParser) ExpectedType() JSONType {
+	return JSONAny
+}
+
+func (p *OneOrManyParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	if tok, err := s.PeekToken(); tok == TokenError {
+		return err
+	} else if tok == TokenArrayBegin {
+		return p.SliceParser.Parse(path, s, v)
+	}
+
+	// check we have a ptr to a slice
+	ptrVal := reflect.ValueOf(v)
+	if ptrVal.Kind() != reflect.Ptr || ptrVal.IsNil() || ptrVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf(ERROR_BAD_SLICE_DEST, ptrVal.Type())
+	}
+	val := ptrVal.Elem()
+
+	// re-use the slice's storage if we can
+	if val.Cap() < 1 {
+		val.Set(reflect.MakeSlice(val.Type(), 1, 1))
+	} else {
+		val.SetLen(1)
+		val.Index(0).Set(reflect.Zero(p.elemType))
+	}
+
+	var errs ValidationError
+	if err := p.schema.Parse(path, s, val.Index(0).Addr().Interface()); err == ErrAbsent {
+		// there's nothing to wrap
+		errs = errs.Add(path(), ERROR_PROP_REQUIRED)
+	} else if err != nil {
+		if verr, ok := err.(ValidationError); ok {
+			errs = errs.AddMany(verr)
+		} else {
+			return err
+		}
+	}
+
+	// validate the contents
+	for _, v := range p.vs {
+		if err := v.ValidateSlice(val); err != nil {
+			errs = errs.Add(path(), err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (p *OneOrManyParser) Validate(path Pather, s *Scanner) error {
+	if tok, err := s.PeekToken(); tok == TokenError {
+		return err
+	} else if tok == TokenArrayBegin {
+		return p.SliceParser.Validate(path, s)
+	}
+
+	// a single value is cheap enough to just parse
+	return p.Parse(path, s, reflect.New(reflect.SliceOf(p.elemType)).Interface())
+}
@@ -20,46 +20,67 @@ func String(vs ...StringValidator) *StringParser {
 	return &StringParser{vs}
 }
 
+func (p *StringParser) ExpectedType() JSONType {
+	return JSONString
+}
+
 func (p *StringParser) Prepare(t reflect.Type) error {
 	if t.Kind() != reflect.String {
-		return fmt.Errorf("Want string not %v", t)
+		return fmt.Errorf(ERROR_PREPARE_DEST, "string", t)
 	}
 
 	return nil
 }
 
 func (p *StringParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	if ss, ok := v.(*string); !ok {
+		return fmt.Errorf(ERROR_BAD_STRING_DEST, reflect.TypeOf(v), path())
+	} else {
+		return p.parse(path, s, ss)
+	}
+}
+
+func (p *StringParser) Validate(path Pather, s *Scanner) error {
+	return p.parse(path, s, nil)
+}
+
+/*
+Reads and validates a string, storing it in ss if it's not nil.
+*/
+func (p *StringParser) parse(path Pather, s *Scanner, ss *string) error {
 	tok, buf, err := s.ReadToken()
 	if tok == TokenError {
 		return err
 	} else if tok != TokenString {
-		return NewSingleVErr(path(), fmt.Sprintf(ERROR_INVALID_STRING, string(buf)))
+		return wrongType(path, s, tok, fmt.Sprintf(ERROR_INVALID_STRING, string(buf)))
 	}
 
-	if ss, ok := v.(*string); !ok {
-		return fmt.Errorf(ERROR_BAD_STRING_DEST, reflect.TypeOf(v), path())
-	} else {
-		// now check for validation errors
-		var errs ValidationError
+	// now check for validation errors
+	var errs ValidationError
 
-		s, ok := Unquote(buf)
-		if !ok {
-			return errs.Add(path(), "Invalid string")
-		}
+	b, ok := UnquoteBytes(buf)
+	if !ok {
+		return errs.Add(path(), "Invalid string")
+	} else if ss == nil && len(p.vs) == 0 {
+		// nothing to keep or check
+		return nil
+	}
 
-		*ss = s
+	str := string(b)
+	if ss != nil {
+		*ss = str
+	}
 
-		// validate the contents
-		for _, v := range p.vs {
-			if err := v.ValidateString(*ss); err != nil {
-				errs = errs.Add(path(), err.Error())
-			}
+	// validate the contents
+	for _, v := range p.vs {
+		if err := v.ValidateString(str); err != nil {
+			errs = errs.Add(path(), err.Error())
 		}
+	}
 
-		if len(errs) > 0 {
-			return errs
-		} else {
-			return nil
-		}
+	if len(errs) > 0 {
+		return errs
+	} else {
+		return nil
 	}
 }
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 /*
@@ -12,12 +14,18 @@ Holds infomation to map a JSON object property to a struct field.
 Note: Whether or not the value any non-slice, non-ptr field is required
 */
 type StructPropInfo struct {
-	schema   SchemaType
-	def      reflect.Value
-	f        field
-	required bool
+	schema    SchemaType
+	def       reflect.Value
+	f         field
+	required  bool
+	transform func(reflect.Value) error
 }
 
+/*
+Maps the JSON property n to the struct field of the same name, see Struct for
+details. If s is nil, the SchemaType registered for the field's type is used,
+see RegisterType.
+*/
 func Prop(n string, s SchemaType) StructPropInfo {
 	return StructPropInfo{
 		schema:   s,
@@ -35,6 +43,50 @@ func PropWithDefault(n string, s SchemaType, d interface{}) StructPropInfo {
 	}
 }
 
+/*
+Same as Prop, but once the value has been parsed and validated, fn is called
+with the field's value so it can be modified in place, e.g. to canonicalise a
+phone number.
+
+fn is not called if the value failed validation, nor for default values. Any
+error fn returns is reported as a validation error for the property.
+*/
+func PropTransform(n string, s SchemaType, fn func(reflect.Value) error) StructPropInfo {
+	p := Prop(n, s)
+	p.transform = fn
+	return p
+}
+
+/*
+Combines lists of props, e.g. to build the schema for a new version of an API
+from the previous version's props:
+
+	v1Props := []StructPropInfo{Prop("Name", String()), Prop("Age", Integer())}
+	v1 := Struct(v1Props...)
+	v2 := Struct(MergeProps(v1Props, Prop("Age", Integer(MinI(0))), Prop("Email", String()))...)
+
+Props in extra replace any prop in base with exactly the same name, keeping its
+position, otherwise they're added at the end. Neither base nor extra are
+modified.
+*/
+func MergeProps(base []StructPropInfo, extra ...StructPropInfo) []StructPropInfo {
+	props := make([]StructPropInfo, len(base), len(base)+len(extra))
+	copy(props, base)
+
+outer:
+	for _, e := range extra {
+		for i := range props {
+			if bytes.Equal(props[i].f.nameBytes, e.f.nameBytes) {
+				props[i] = e
+				continue outer
+			}
+		}
+		props = append(props, e)
+	}
+
+	return props
+}
+
 /*
 A simple mapping of a JSON object to a Golang Struct.
 
@@ -93,7 +145,24 @@ the JSON parser for this type. Any field that is present on the struct but not
 present in the props list, will be ignored and left untouched by the Parser.
 */
 type StructParser struct {
-	props []StructPropInfo
+	props     []StructPropInfo
+	onUnknown func(path, name string)
+	strict    bool // see Strict
+	exact     bool // see Exact
+	noDups    bool // see RejectDuplicateKeys
+	groups    []propGroup
+	dotted    bool // see DottedKeys
+	rawName   string
+	rawIndex  []int // index of the rawName field, see CaptureRaw
+}
+
+/*
+A requirement on how many of a group of props must be present.
+*/
+type propGroup struct {
+	names   []string
+	props   []int // index of each named prop, filled in by Prepare
+	exactly bool  // exactly one, rather than at least one
 }
 
 /*
@@ -135,7 +204,124 @@ dereference and for writing to a DB, a nil is obviously different to a default
 value.
 */
 func Struct(props ...StructPropInfo) *StructParser {
-	return &StructParser{props}
+	// take a copy, Prepare updates the props and they may be shared, e.g. via
+	// MergeProps
+	return &StructParser{props: append([]StructPropInfo(nil), props...)}
+}
+
+/*
+Builds a StructParser from a map of property name to SchemaType, for schemas
+that are assembled at runtime, e.g. from config.
+
+The props are added in name order, so the parser (and any errors it returns) is
+the same each time for the same map. As with Struct, whether or not a property
+is required is decided by the field it maps to. Use AddPropWithDefault to add
+any properties with default values.
+*/
+func StructFromMap(props map[string]SchemaType) *StructParser {
+	names := make([]string, 0, len(props))
+	for n := range props {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	p := Struct()
+	for _, n := range names {
+		p.AddProp(n, props[n])
+	}
+	return p
+}
+
+/*
+Adds a property to the parser, the same as if Prop(n, s) was passed to Struct.
+
+Must be called before the parser is Prepared, i.e. before it is passed to
+Parser.
+*/
+func (p *StructParser) AddProp(n string, s SchemaType) *StructParser {
+	p.props = append(p.props, Prop(n, s))
+	return p
+}
+
+/*
+Adds a property to the parser, the same as if PropWithDefault(n, s, d) was
+passed to Struct.
+
+Must be called before the parser is Prepared, i.e. before it is passed to
+Parser.
+*/
+func (p *StructParser) AddPropWithDefault(n string, s SchemaType, d interface{}) *StructParser {
+	p.props = append(p.props, PropWithDefault(n, s, d))
+	return p
+}
+
+/*
+Sets a callback that's called with the object's path and the name of each
+property that doesn't match any of the parser's props, once its value has been
+skipped over, e.g. for monitoring how often clients send unexpected fields.
+*/
+func (p *StructParser) OnUnknownField(fn func(path, name string)) *StructParser {
+	p.onUnknown = fn
+	return p
+}
+
+/*
+Reports properties that don't match any of the parser's props as
+ValidationErrors at their path, rather than skipping them. As with other
+validation errors, the rest of the object is still parsed, so every unknown
+property is reported.
+*/
+func (p *StructParser) Strict() *StructParser {
+	p.strict = true
+	return p
+}
+
+/*
+Requires the JSON object to have exactly the parser's props, no more and no
+less, e.g. for strict protocols and contract tests.
+
+As with Strict, unknown props are reported as ValidationErrors at their path,
+and every prop is required, even pointer fields and props with default values.
+*/
+func (p *StructParser) Exact() *StructParser {
+	p.strict = true
+	p.exact = true
+	return p
+}
+
+/*
+Reports a property that appears more than once in the same object, known to
+the parser or not, as a ValidationError at its path. The first value is kept
+and any repeats are skipped.
+*/
+func (p *StructParser) RejectDuplicateKeys() *StructParser {
+	p.noDups = true
+	return p
+}
+
+/*
+Requires that at least one of the named props is present in the JSON object,
+e.g. a login that accepts either an Email or a Phone.
+
+Props with default values only count if they're actually present. Failures are
+reported against the object's path.
+*/
+func (p *StructParser) AtLeastOneOf(names ...string) *StructParser {
+	p.groups = append(p.groups, propGroup{names: names})
+	return p
+}
+
+/*
+Same as AtLeastOneOf, but also fails if more than one of the named props is
+present.
+*/
+func (p *StructParser) ExactlyOneOf(names ...string) *StructParser {
+	p.groups = append(p.groups, propGroup{names: names, exactly: true})
+	return p
+}
+
+func (p *StructParser) ExpectedType() JSONType {
+	return JSONObject
 }
 
 /*
@@ -146,25 +332,72 @@ func (p *StructParser) Prepare(t reflect.Type) error {
 	if t.Kind() != reflect.Struct {
 		return fmt.Errorf(ERROR_BAD_OBJ_DEST, t)
 	}
+	if err := p.prepareRaw(t); err != nil {
+		return err
+	}
 
-	// fill in the field for each prop
-	fields := typeFields(t)
-	for i := range fields {
-		f := &fields[i]
-		var prop *StructPropInfo
+	// find the props for each group, before they're renamed to their field
+	for i := range p.groups {
+		g := &p.groups[i]
+		g.props = g.props[:0]
+		for _, n := range g.names {
+			pi := -1
+			for j := range p.props {
+				if bytes.Equal(p.props[j].f.nameBytes, []byte(n)) {
+					pi = j
+					break
+				} else if pi < 0 && bytes.EqualFold(p.props[j].f.nameBytes, []byte(n)) {
+					pi = j
+				}
+			}
 
-		// find the prop for this field
-		for j := range p.props {
-			pr := &p.props[j]
+			if pi < 0 {
+				return NewSchemaConfigError("No prop %q for group %v on struct %v", n, g.names, t)
+			}
+			g.props = append(g.props, pi)
+		}
+	}
 
-			if bytes.Equal(f.nameBytes, pr.f.nameBytes) {
-				prop = pr
-				break
+	// find the prop for each field, exact name matches take precedence over
+	// case-insensitive ones and there must never be more than one prop
+	fields := typeFields(t)
+	fieldProps := make([]*StructPropInfo, len(fields)) // the prop for each field
+	propFields := make([]*field, len(p.props))         // the field for each prop
+	for _, exact := range []bool{true, false} {
+		for i := range fields {
+			f := &fields[i]
+			if fieldProps[i] != nil {
+				continue
 			}
-			if prop == nil && f.equalFold(f.nameBytes, pr.f.nameBytes) {
-				prop = pr
+
+			for j := range p.props {
+				pr := &p.props[j]
+
+				if exact && !bytes.Equal(f.nameBytes, pr.f.nameBytes) {
+					continue
+				} else if !exact && !f.equalFold(f.nameBytes, pr.f.nameBytes) {
+					continue
+				}
+
+				if other := fieldProps[i]; other != nil {
+					return NewSchemaConfigError("Props %q and %q both map to field %v on struct %v", other.f.nameBytes, pr.f.nameBytes, f.name, t)
+				} else if other := propFields[j]; other != nil {
+					if !exact && bytes.Equal(other.nameBytes, pr.f.nameBytes) {
+						// already has an exact match
+						continue
+					}
+					return NewSchemaConfigError("Prop %q maps to both fields %v and %v on struct %v", pr.f.nameBytes, other.name, f.name, t)
+				}
+				fieldProps[i] = pr
+				propFields[j] = f
 			}
 		}
+	}
+
+	// fill in the field for each prop
+	for i := range fields {
+		f := &fields[i]
+		prop := fieldProps[i]
 
 		// save info and Prepare the Schema if needed
 		if prop != nil {
@@ -189,9 +422,19 @@ func (p *StructParser) Prepare(t reflect.Type) error {
 			// concrete type
 			ft := t.FieldByIndex(f.index)
 			prop.required = ft.Type.Kind() != reflect.Ptr
+
+			// props without a schema use the one registered for their type
+			if prop.schema == nil {
+				schema, ok := registeredSchema(f.typ)
+				if !ok {
+					return NewSchemaConfigError("Prop %q has no schema and no type is registered for %v", prop.f.nameBytes, f.typ)
+				}
+				prop.schema = schema
+			}
+
 			if ps, ok := prop.schema.(PreparedSchemaType); ok {
 				if err := ps.Prepare(f.typ); err != nil {
-					return err
+					return propPrepareError(err, prop.f.nameBytes, t)
 				}
 			}
 		}
@@ -200,9 +443,8 @@ func (p *StructParser) Prepare(t reflect.Type) error {
 	// check we found a field for each prop
 	missingFields := make([]string, 0, 32)
 	for i := range p.props {
-		pr := &p.props[i]
-		if pr.f.index == nil {
-			missingFields = append(missingFields, pr.f.name)
+		if propFields[i] == nil {
+			missingFields = append(missingFields, string(p.props[i].f.nameBytes))
 		}
 	}
 	if len(missingFields) > 0 {
@@ -212,6 +454,22 @@ func (p *StructParser) Prepare(t reflect.Type) error {
 	return nil
 }
 
+/*
+Adds the prop's name, and the struct it's on, to an error from preparing the
+prop's schema, e.g. `prop "Name" on Person: want string destination, got int64`.
+*/
+func propPrepareError(err error, name []byte, t reflect.Type) error {
+	typeName := t.Name()
+	if typeName == "" {
+		typeName = t.String()
+	}
+
+	if _, ok := err.(*SchemaConfigError); ok {
+		return NewSchemaConfigError(ERROR_PREPARE_PROP, name, typeName, err)
+	}
+	return fmt.Errorf(ERROR_PREPARE_PROP, name, typeName, err)
+}
+
 func (p *StructParser) getProp(name []byte) (int, *StructPropInfo) {
 	// get the property
 	var prop *StructPropInfo
@@ -237,16 +495,36 @@ func (p *StructParser) getProp(name []byte) (int, *StructPropInfo) {
 Won't allocate the struct, but will allocate fields if needed.
 */
 func (p *StructParser) Parse(path Pather, s *Scanner, v interface{}) error {
-	// check we have a ptr to a struct
-	ptrVal := reflect.ValueOf(v)
-	ptrType := ptrVal.Type()
-	if ptrType.Kind() != reflect.Ptr || ptrVal.IsNil() {
-		return fmt.Errorf(ERROR_BAD_OBJ_DEST, ptrVal.Type())
+	val, err := structValue(v)
+	if err != nil {
+		return err
 	}
-	val := ptrVal.Elem()
-	valType := val.Type()
-	if valType.Kind() != reflect.Struct {
-		return fmt.Errorf(ERROR_BAD_OBJ_DEST, ptrVal.Type())
+	return p.parse(path, s, val)
+}
+
+/*
+Checks the object's props without storing them, defaults are not applied.
+*/
+func (p *StructParser) Validate(path Pather, s *Scanner) error {
+	return p.parse(path, s, reflect.Value{})
+}
+
+/*
+Parses the object into val, or, if val is the zero Value, just validates it.
+*/
+func (p *StructParser) parse(path Pather, s *Scanner, val reflect.Value) error {
+	if p.rawName != "" {
+		return p.parseRaw(path, s, val)
+	}
+	return p.parseObject(path, s, val)
+}
+
+func (p *StructParser) parseObject(path Pather, s *Scanner, val reflect.Value) error {
+	if p.dotted {
+		var err error
+		if s, err = expandDottedKeys(s); err != nil {
+			return err
+		}
 	}
 
 	// read the '{'
@@ -256,6 +534,21 @@ func (p *StructParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	} else if tok != TokenObjectBegin {
 		return NewParseError("Expected '{' not " + tok.String())
 	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+
+	// a fast path for {}, which is common, with nothing to set up, but props
+	// are still required and defaults applied
+	if tok, err := s.PeekToken(); err != nil {
+		return err
+	} else if tok == TokenObjectEnd {
+		if _, _, err := s.ReadToken(); err != nil {
+			return err
+		}
+		return p.finish(path, val, nil, nil)
+	}
 
 	// we'll accumulate validation errors into this
 	var errs ValidationError
@@ -264,23 +557,38 @@ func (p *StructParser) Parse(path Pather, s *Scanner, v interface{}) error {
 	// reused to reference the prop
 	var prop *StructPropInfo
 	var propIndex int
+	// name of an unknown prop, only kept if we need it for onUnknown, strict or
+	// noDups
+	var unknownName string
 	propPath := func() string {
-		return fmt.Sprintf("%s%s", path(), prop.f.name)
+		return path() + prop.f.name
+	}
+	// the keys seen so far, if we're looking for duplicates
+	var seenProps []bool
+	var seenUnknown map[string]bool
+	if p.noDups {
+		seenProps = make([]bool, len(p.props))
 	}
 
-	for {
+	for n := 0; ; n++ {
 		// read the key, or '}'
-		if tok, keyb, err := s.ReadToken(); tok == TokenError {
+		if tok, keyb, err := s.ReadKey(); tok == TokenError {
 			return err
 		} else if tok == TokenObjectEnd {
+			if n > 0 && !s.allowTrailingComma {
+				return NewParseError(ERROR_TRAILING_COMMA_OBJ)
+			}
 			break
 		} else if tok != TokenString {
 			return NewParseError("Expected object property name or '}' not " + tok.String())
+		} else if err := s.element(n, len(keyb)); err != nil {
+			return err
 		} else {
 			// get the appropriate prop
 			// we do this now, because ReadToken will invalidate keyb
 			propIndex, prop = p.getProp(keyb[1 : len(keyb)-1])
-			if prop != nil {
+			if prop == nil && (p.onUnknown != nil || p.strict || p.noDups) {
+				unknownName, _ = Unquote(keyb)
 			}
 		}
 
@@ -291,37 +599,46 @@ func (p *StructParser) Parse(path Pather, s *Scanner, v interface{}) error {
 			return NewParseError("Expected ':' not " + tok.String())
 		}
 
-		if prop == nil {
+		// is it a repeat?
+		dup := false
+		if p.noDups && prop != nil {
+			dup = seenProps[propIndex]
+			seenProps[propIndex] = true
+		} else if p.noDups {
+			if seenUnknown == nil {
+				seenUnknown = map[string]bool{}
+			}
+			dup = seenUnknown[unknownName]
+			seenUnknown[unknownName] = true
+		}
+
+		if dup {
 			if err := s.SkipValue(); err != nil {
 				return err
 			}
-		} else {
-			// walk to the actual value and allocate if needed
-			propval := val
-			for _, i := range prop.f.index {
-				propval = propval.Field(i)
-				if propval.Kind() == reflect.Ptr {
-					if propval.IsNil() {
-						propval.Set(reflect.New(propval.Type().Elem()))
-					}
-					propval = propval.Elem()
-				}
+			if prop != nil {
+				errs = errs.Add(propPath(), ERROR_DUPLICATE_KEY)
+			} else {
+				errs = errs.Add(path()+unknownName, ERROR_DUPLICATE_KEY)
 			}
-
-			// parse the value
-			if err := prop.schema.Parse(propPath, s, propval.Addr().Interface()); err != nil {
-				if verr, ok := err.(ValidationError); ok {
-					// just a validation error, was valid JSON at least collect
-					// any more validation errors that we can
-					errs = errs.AddMany(verr)
-				} else {
-					// an error that means we can't recover, so bail right now.
-					return err
-				}
+		} else if prop == nil {
+			if err := s.SkipValue(); err != nil {
+				return err
+			}
+			if p.onUnknown != nil {
+				p.onUnknown(path(), unknownName)
+			}
+			if p.strict {
+				errs = errs.Add(path()+unknownName, ERROR_PROP_UNKNOWN)
+			}
+		} else {
+			var got bool
+			if got, errs, err = prop.parse(propPath, s, val, errs); err != nil {
+				return err
+			} else if got {
+				// we got it!!
+				gotProps[propIndex] = true
 			}
-
-			// we got it!!
-			gotProps[propIndex] = true
 		}
 
 		// we want a , or a }
@@ -330,37 +647,156 @@ func (p *StructParser) Parse(path Pather, s *Scanner, v interface{}) error {
 		} else if tok == TokenObjectEnd {
 			break
 		} else if tok == TokenItemSep {
-			// Note this a trailing ',' before the '}'
+			// a trailing ',' before the '}' is checked for when reading the key
 			continue
 		} else {
 			return NewParseError("Expected ',' or '}' not " + tok.String())
 		}
 	}
 
-	// check we got all the required fields
-	for i, prop := range p.props {
-		if gotProps[i] {
+	return p.finish(path, val, gotProps, errs)
+}
+
+/*
+Gets the struct value that v points to, or an error if it doesn't point to one.
+*/
+func structValue(v interface{}) (reflect.Value, error) {
+	// check we have a ptr to a struct
+	ptrVal := reflect.ValueOf(v)
+	ptrType := ptrVal.Type()
+	if ptrType.Kind() != reflect.Ptr || ptrVal.IsNil() {
+		return reflect.Value{}, fmt.Errorf(ERROR_BAD_OBJ_DEST, ptrVal.Type())
+	}
+	val := ptrVal.Elem()
+	if val.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf(ERROR_BAD_OBJ_DEST, ptrVal.Type())
+	}
+	return val, nil
+}
+
+/*
+Walks to the prop's field within val, allocating any nil pointers on the way,
+to any depth, e.g. both levels of a **string field.
+
+If the field itself is a nil pointer, the outermost newly allocated pointer is
+also returned so it can be reset later if needed.
+*/
+func (prop *StructPropInfo) fieldValue(val reflect.Value) (propval, allocated reflect.Value) {
+	propval = val
+	for j, i := range prop.f.index {
+		propval = propval.Field(i)
+		for propval.Kind() == reflect.Ptr {
+			if propval.IsNil() {
+				propval.Set(reflect.New(propval.Type().Elem()))
+				if j == len(prop.f.index)-1 && !allocated.IsValid() {
+					allocated = propval
+				}
+			}
+			propval = propval.Elem()
+		}
+	}
+	return
+}
+
+/*
+Parses the prop's value from s into its field within val, collecting any
+validation errors into errs.
+
+Returns false if the value turned out to be absent (see ErrAbsent), in which
+case the field is left as it was. Any returned error means parsing can't
+continue.
+*/
+func (prop *StructPropInfo) parse(path Pather, s *Scanner, val reflect.Value, errs ValidationError) (bool, ValidationError, error) {
+	var propval, allocated reflect.Value
+	if val.IsValid() {
+		propval, allocated = prop.fieldValue(val)
+	} else if prop.transform == nil {
+		// just validating
+		return prop.result(path, s, validateValue(prop.schema, prop.f.typ, path, s), errs)
+	} else {
+		// the transform needs a value to work on
+		propval = reflect.New(prop.f.typ).Elem()
+	}
+
+	if err := prop.schema.Parse(path, s, propval.Addr().Interface()); err == ErrAbsent {
+		// treat it as if we never saw it
+		if allocated.IsValid() {
+			allocated.Set(reflect.Zero(allocated.Type()))
+		}
+		return false, errs, nil
+	} else if err != nil {
+		if verr, ok := err.(ValidationError); ok {
+			// just a validation error, was valid JSON at least collect
+			// any more validation errors that we can
+			if errs, err = s.addErrors(errs, verr); err != nil {
+				return false, errs, err
+			}
+		} else {
+			// an error that means we can't recover, so bail right now.
+			return false, errs, err
+		}
+	} else if prop.transform != nil {
+		if err := prop.transform(propval); err != nil {
+			errs = errs.Add(path(), err.Error())
+		}
+	}
+
+	return true, errs, nil
+}
+
+/*
+Interprets the error from validating, but not parsing, the prop's value in the
+same way as parse.
+*/
+func (prop *StructPropInfo) result(path Pather, s *Scanner, err error, errs ValidationError) (bool, ValidationError, error) {
+	if err == ErrAbsent {
+		return false, errs, nil
+	} else if verr, ok := err.(ValidationError); ok {
+		errs, err = s.addErrors(errs, verr)
+		return err == nil, errs, err
+	} else if err != nil {
+		return false, errs, err
+	}
+	return true, errs, nil
+}
+
+/*
+Applies defaults to, or reports as required, all the props we didn't get, then
+returns all the validation errors, if any. Defaults are skipped if val is the
+zero Value.
+*/
+func (p *StructParser) finish(path Pather, val reflect.Value, gotProps []bool, errs ValidationError) error {
+	// check we got all the required fields, gotProps is nil if there were none
+	for i := range p.props {
+		prop := &p.props[i]
+		if gotProps != nil && gotProps[i] {
 			continue
 		}
 
-		// does it have a default??
-		if prop.def.IsValid() {
-			// get a value referencing the firld
-			propval := val
-			for _, i := range prop.f.index {
-				propval = propval.Field(i)
-				if propval.Kind() == reflect.Ptr {
-					if propval.IsNil() {
-						propval.Set(reflect.New(propval.Type().Elem()))
-					}
-					propval = propval.Elem()
-				}
+		// does it have a default?? not if every prop is required
+		if prop.def.IsValid() && !p.exact {
+			if val.IsValid() {
+				propval, _ := prop.fieldValue(val)
+				propval.Set(prop.def)
+			}
+		} else if prop.required || p.exact {
+			errs = errs.Add(path()+prop.f.name, ERROR_PROP_REQUIRED)
+		}
+	}
+
+	// check the groups
+	for _, g := range p.groups {
+		count := 0
+		for _, pi := range g.props {
+			if gotProps != nil && gotProps[pi] {
+				count++
 			}
+		}
 
-			// now set it
-			propval.Set(prop.def)
-		} else if prop.required {
-			errs = errs.Add(path()+p.props[i].f.name, ERROR_PROP_REQUIRED)
+		if count == 0 {
+			errs = errs.Add(path(), fmt.Sprintf(ERROR_AT_LEAST_ONE_OF, strings.Join(g.names, ", ")))
+		} else if g.exactly && count > 1 {
+			errs = errs.Add(path(), fmt.Sprintf(ERROR_EXACTLY_ONE_OF, strings.Join(g.names, ", ")))
 		}
 	}
 
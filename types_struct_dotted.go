@@ -0,0 +1,101 @@
+package jsonv
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+/*
+Treats keys with dots in them as paths to the props of nested objects, for
+clients that post flattened objects, e.g. this is parsed as though it were
+{"User": {"Name": "Bob", "Age": 24}}:
+
+	{"User.Name": "Bob", "User.Age": 24}
+
+Only this object's keys are expanded, the nested props are parsed by their own
+schemas as normal, so "User" must be a prop whose schema is a Struct (or Map,
+etc.). Plain and dotted keys can be mixed.
+
+As with any repeated key, if a prefix is given more than once, e.g. both "User"
+and "User.Name", the last one wins, so a dotted key replaces any earlier value
+for its prefix rather than merging into it, and vice versa. Dotted keys with
+the same prefix are always merged.
+
+The object is read into memory to be rearranged before it's parsed, so the
+number of keys is limited by MaxObjectKeys.
+*/
+func (p *StructParser) DottedKeys() *StructParser {
+	p.dotted = true
+	return p
+}
+
+/*
+A JSON value, either one read as is, or an object built from dotted keys.
+*/
+type dottedNode struct {
+	raw   []byte
+	keys  []string // in the order they were first seen
+	props map[string]*dottedNode
+}
+
+func (n *dottedNode) set(path []string, raw []byte) {
+	c, ok := n.props[path[0]]
+	if !ok {
+		if n.props == nil {
+			n.props = make(map[string]*dottedNode)
+		}
+		c = &dottedNode{}
+		n.props[path[0]] = c
+		n.keys = append(n.keys, path[0])
+	}
+
+	if len(path) == 1 {
+		// replaces any dotted keys
+		c.raw, c.keys, c.props = raw, nil, nil
+	} else {
+		// replaces any value
+		c.raw = nil
+		c.set(path[1:], raw)
+	}
+}
+
+func (n *dottedNode) appendJSON(b []byte) []byte {
+	if n.raw != nil {
+		return append(b, n.raw...)
+	}
+
+	b = append(b, '{')
+	for i, k := range n.keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		kb, _ := json.Marshal(k)
+		b = append(b, kb...)
+		b = append(b, ':')
+		b = n.props[k].appendJSON(b)
+	}
+	return append(b, '}')
+}
+
+/*
+Reads the next object from s, and returns a Scanner for the same object with
+its dotted keys expanded into nested objects.
+*/
+func expandDottedKeys(s *Scanner) (*Scanner, error) {
+	var root dottedNode
+
+	// any errors here are malformed input, there's no schema to validate yet
+	err := forEachPair(rootPath, s, func(keyPath Pather, key string) error {
+		raw, err := s.appendValue(nil)
+		if err != nil {
+			return err
+		}
+		root.set(strings.Split(key, "."), raw)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.subScanner(root.appendJSON(nil)), nil
+}
@@ -0,0 +1,124 @@
+package jsonv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+Maps a JSON array onto a Golang Struct by position rather than by property
+name, e.g. ["Angelo",24,["Bob"]] for the props Name, Age, Friends.
+
+Array element i is parsed by prop i, with the same field mapping, required
+and default rules as Struct. Missing trailing elements are treated as absent
+properties, so they take their default or are reported as required. Any extra
+elements are reported as an error against the array as a whole.
+*/
+type PositionalStructParser struct {
+	StructParser
+}
+
+func StructPositional(props ...StructPropInfo) *PositionalStructParser {
+	return &PositionalStructParser{*Struct(props...)}
+}
+
+func (p *PositionalStructParser) ExpectedType() JSONType {
+	return JSONArray
+}
+
+func (p *PositionalStructParser) Parse(path Pather, s *Scanner, v interface{}) error {
+	val, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	return p.parse(path, s, val)
+}
+
+func (p *PositionalStructParser) Validate(path Pather, s *Scanner) error {
+	return p.parse(path, s, reflect.Value{})
+}
+
+/*
+Parses the array into val, or, if val is the zero Value, just validates it.
+*/
+func (p *PositionalStructParser) parse(path Pather, s *Scanner, val reflect.Value) error {
+	// read the '['
+	tok, _, err := s.ReadToken()
+	if tok == TokenError {
+		return err
+	} else if tok != TokenArrayBegin {
+		return NewParseError("Expected '[' not %v", tok)
+	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+
+	finished := false
+
+	// see if we have at least 1 value
+	if tok, err := s.PeekToken(); err != nil {
+		return err
+	} else if tok == TokenArrayEnd {
+		// actually consume it
+		if _, _, err := s.ReadToken(); err != nil {
+			return err
+		}
+		finished = true
+	}
+
+	var errs ValidationError
+	gotProps := make([]bool, len(p.props))
+
+	// now read val then ','|']'
+	i := 0
+	itemPath := func() string {
+		return fmt.Sprintf("%s%s", path(), p.props[i].f.name)
+	}
+	for !finished {
+		if err := s.element(i, 0); err != nil {
+			return err
+		}
+
+		if i < len(p.props) {
+			var got bool
+			if got, errs, err = p.props[i].parse(itemPath, s, val, errs); err != nil {
+				return err
+			}
+			gotProps[i] = got
+		} else if err := s.SkipValue(); err != nil {
+			// extra item, checked for once we know how many there are
+			return err
+		}
+
+		// we want either a ',' or a ']'
+		if tok, _, err := s.ReadToken(); tok == TokenError {
+			return err
+		} else if tok == TokenArrayEnd {
+			finished = true
+		} else if tok == TokenItemSep {
+			if tok, err := s.PeekToken(); err != nil {
+				return err
+			} else if tok == TokenArrayEnd {
+				if !s.allowTrailingComma {
+					return NewParseError(ERROR_TRAILING_COMMA_ARR)
+				}
+				// actually consume it
+				if _, _, err := s.ReadToken(); err != nil {
+					return err
+				}
+				finished = true
+			}
+		} else {
+			return NewParseError("After element %d, expected ',' or ']' not %v", i, tok)
+		}
+
+		i++
+	}
+
+	if i > len(p.props) {
+		errs = errs.Add(path(), fmt.Sprintf(ERROR_POSITIONAL, len(p.props), i))
+	}
+
+	return p.finish(path, val, gotProps, errs)
+}
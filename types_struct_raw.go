@@ -0,0 +1,58 @@
+package jsonv
+
+import (
+	"reflect"
+)
+
+/*
+Stores the object's original JSON, exactly as it was read, whitespace and all,
+in the struct's []byte field named field, e.g. a json.RawMessage, as well as
+parsing it as normal. This is useful for audit logs that need to record what
+was actually sent without a second pass over the input.
+
+The field is set even when there are validation errors. It's always a copy, so
+the object is held in memory twice, once as it was sent and once parsed, and
+its size is only limited by the Scanner's limits, e.g. MaxElements.
+*/
+func (p *StructParser) CaptureRaw(field string) *StructParser {
+	p.rawName = field
+	return p
+}
+
+/*
+Finds the CaptureRaw field in t, if there is one.
+*/
+func (p *StructParser) prepareRaw(t reflect.Type) error {
+	if p.rawName == "" {
+		return nil
+	}
+
+	f, ok := t.FieldByName(p.rawName)
+	if !ok || f.PkgPath != "" {
+		return NewSchemaConfigError(ERROR_PREPARE_RAW, p.rawName, t)
+	} else if f.Type.Kind() != reflect.Slice || f.Type.Elem().Kind() != reflect.Uint8 {
+		return NewSchemaConfigError(ERROR_PREPARE_RAW, p.rawName, t)
+	}
+
+	p.rawIndex = f.Index
+	return nil
+}
+
+/*
+Parses the object as parseObject does, capturing its original JSON.
+*/
+func (p *StructParser) parseRaw(path Pather, s *Scanner, val reflect.Value) error {
+	// capture from the first byte of the object, not any space before it
+	if tok, err := s.PeekToken(); tok == TokenError {
+		return err
+	}
+
+	c := s.startCapture()
+	err := p.parseObject(path, s, val)
+	raw := s.endCapture(c)
+
+	if _, ok := err.(ValidationError); (err == nil || ok) && val.IsValid() {
+		val.FieldByIndex(p.rawIndex).SetBytes(raw)
+	}
+	return err
+}
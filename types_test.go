@@ -2,10 +2,15 @@ package jsonv
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -88,6 +93,18 @@ func Test_SchemaTypeParse(t *testing.T) {
 		{Integer(), "24", int64(24)},
 		{Integer(), "572", int64(572)},
 		{Integer(), "-572", int64(-572)},
+		{Integer(), "2000000000", int32(2000000000)},
+		{Integer(), "2000000000", uint32(2000000000)},
+		{Integer(), "4000000000", uint32(4000000000)},
+		{Integer(), "200", uint8(200)},
+		{Integer(), "-0", uint8(0)},
+		{Integer(), "18446744073709551615", uint64(math.MaxUint64)},
+		{Integer(MinI(1)), "18446744073709551615", uint(math.MaxUint64)},
+		{Integer(), "8984", rune('⌘')},
+
+		{Float(), "24", float64(24)},
+		{Float(), "-2.5", float32(-2.5)},
+		{Float(MinF(0), MaxF(10)), "0.5e1", float64(5)},
 
 		{Boolean(), "true", true},
 		{Boolean(), "false", false},
@@ -100,8 +117,11 @@ func Test_SchemaTypeParse(t *testing.T) {
 
 		{Date(), `"2015-05-21"`, mkDate(2015, 5, 21)},
 		{DateTime(), `"2022-05-21 11:11:11"`, mkDateTime(2022, 5, 21, 11, 11, 11)},
+		{DateTime(), `"2022-05-21T11:11:11Z"`, mkDateTime(2022, 5, 21, 11, 11, 11)},
 
 		{Enum(Integer(), int64(1), int64(2)), "1", int64(1)},
+		{Enum(Integer(), int64(1), int(2)), "2", int64(2)},
+		{Enum(Integer(), 1, 2), "2", uint8(2)},
 		{Enum(String(), "avail", "dud"), `"dud"`, "dud"},
 		{Enum(Boolean(), false), `false`, false},
 
@@ -252,13 +272,20 @@ func Test_SchemaTypeValidationErrors(t *testing.T) {
 		{Integer(), "512", new(int8), []string{"/"}},
 		{Integer(), "70000", new(int16), []string{"/"}},
 		{Integer(), "4000000000", new(int32), []string{"/"}},
+		{Integer(), "256", new(uint8), []string{"/"}},
+		{Integer(), "-1", new(uint8), []string{"/"}},
+		{Integer(), "18446744073709551616", new(uint64), []string{"/"}},
+		{Integer(MaxI(1000)), "18446744073709551615", new(uint64), []string{"/"}},
 		{Integer(MinI(7)), "5", new(int64), []string{"/"}},
 		{Integer(MaxI(3)), "5", new(int64), []string{"/"}},
+		{Float(MaxF(1)), "1.5", new(float64), []string{"/"}},
+		{Float(), `"1.5"`, new(float32), []string{"/"}},
 
 		{String(MaxLen(2)), `"TOo long"`, new(string), []string{"/"}},
 
 		{Date(), `"4 Jan 2021"`, new(time.Time), []string{"/"}},
-		{DateTime(), `"2022-03-10T23:00:00.000Z"`, new(time.Time), []string{"/"}},
+		{DateTime(), `"10/03/2022 23:00:00"`, new(time.Time), []string{"/"}},
+		{DateTime(), `"2022-03-10T23:00:00"`, new(time.Time), []string{"/"}},
 
 		{Enum(Integer(), int64(1), int64(2)), "3", new(int64), []string{"/"}},
 		{Enum(String(), "avail", "dud"), `"dude"`, new(string), []string{"/"}},
@@ -305,3 +332,2164 @@ func Test_SchemaTypeValidationErrors(t *testing.T) {
 		}
 	}
 }
+
+func Test_SliceStructuralErrorIndex(t *testing.T) {
+	// a large array with a missing ',' between elements 700 and 701
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i := 0; i < 1000; i++ {
+		if i > 0 && i != 701 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, "%d ", i)
+	}
+	buf.WriteString("]")
+
+	var dest []int64
+	err := tryParse(Slice(Integer()), buf.String(), &dest, dest)
+	if perr, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got error %v, want a ParseError", err)
+	} else if want := "After element 700, expected ',' or ']' not number"; perr.Error() != want {
+		t.Fatalf("Got \"%v\", want \"%v\"", perr, want)
+	}
+}
+
+func Test_EmptyCollections(t *testing.T) {
+	// [] keeps reused storage but empties it, and still runs slice validators
+	dest := []int64{1, 2, 3}
+	s := NewScanner(bytes.NewBufferString(` [ ] `))
+	if err := Slice(Integer()).Parse(func() string { return "/" }, s, &dest); err != nil {
+		t.Fatal(err)
+	} else if dest == nil || len(dest) != 0 {
+		t.Fatalf("Got %#v, want an empty non-nil slice", dest)
+	}
+
+	s = NewScanner(bytes.NewBufferString(`[]`))
+	err := Slice(Integer(), MinItems(1)).Parse(func() string { return "/" }, s, &dest)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/" {
+		t.Fatalf("Got error %v, want one at \"/\"", err)
+	}
+
+	// {} still reports required props
+	var st simpleStruct
+	err = tryParse(Struct(Prop("Captcha", String())), `{ }`, &st, st)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/Captcha" {
+		t.Fatalf("Got error %v, want one at \"/Captcha\"", err)
+	}
+}
+
+func Test_RawJSON(t *testing.T) {
+	type envelope struct {
+		Kind    string
+		Payload json.RawMessage
+		Extra   []byte
+	}
+	schema := Struct(Prop("Kind", String()), Prop("Payload", RawJSON()), Prop("Extra", RawJSON()))
+
+	doc := `{"Kind": "a", "Payload": {"x": [1, 2.5, "\u0041"], "y" : null}, "Extra":"str" }`
+	want := envelope{"a", []byte(`{"x": [1, 2.5, "\u0041"], "y" : null}`), []byte(`"str"`)}
+	for i, dec := range []func(string) io.Reader{
+		func(j string) io.Reader { return bytes.NewBufferString(j) },
+		func(j string) io.Reader { return &oneByteReader{bytes.NewReader([]byte(j))} },
+	} {
+		var got envelope
+		if err := Parser(&got, schema).Parse(dec(doc), &got); err != nil {
+			t.Fatalf("Reader %d: %v", i, err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Reader %d: Got %q, want %q", i, got, want)
+		}
+	}
+
+	// it must still be JSON
+	var got envelope
+	err := Parser(&got, schema).Parse(bytes.NewBufferString(`{"Kind": "a", "Payload": [1,}, "Extra": 1}`), &got)
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got %v, want a ParseError", err)
+	}
+}
+
+func Test_Null(t *testing.T) {
+	type patch struct {
+		Name      *string
+		ClearName bool
+		Other     int64
+	}
+	schema := Struct(
+		Prop("Name", String()),
+		Prop("ClearName", Null()),
+		Prop("Other", Null()),
+	)
+
+	want := patch{ClearName: true, Other: 7}
+	got := patch{Other: 7}
+	if err := tryParse(schema, `{"ClearName": null, "Other": null}`, &got, want); err != nil {
+		t.Fatal(err)
+	}
+
+	got = patch{}
+	err := tryParse(schema, `{"ClearName": false, "Other": null}`, &got, got)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/ClearName" {
+		t.Fatalf("Got error %v, want one at \"/ClearName\"", err)
+	} else if want := fmt.Sprintf(ERROR_INVALID_NULL, "false"); verr[0].Error != want {
+		t.Fatalf("Got error %q, want %q", verr[0].Error, want)
+	}
+}
+
+func Test_Nullable(t *testing.T) {
+	type person struct {
+		Name string
+		Age  *int64
+		Tags []*string
+	}
+	schema := Struct(
+		Prop("Name", Nullable(String())),
+		Prop("Age", Nullable(Integer(MinI(0)))),
+		Prop("Tags", Slice(Nullable(String()))),
+	)
+
+	age, tag := int64(3), "a"
+	cases := []struct {
+		json  string
+		want  person
+		paths []string
+	}{
+		{`{"Name": "Zing", "Age": 3, "Tags": ["a"]}`, person{"Zing", &age, []*string{&tag}}, nil},
+		{`{"Name": "Zing", "Age": null, "Tags": [null, "a"]}`, person{"Zing", nil, []*string{nil, &tag}}, nil},
+		{`{"Name": null, "Tags": []}`, person{Tags: []*string{}}, []string{"/Name"}},
+		{`{"Name": "Zing", "Age": -1, "Tags": [true]}`, person{}, []string{"/Age", "/Tags0/"}},
+	}
+
+	for i, c := range cases {
+		var got person
+		err := Parser(&got, schema).Parse(bytes.NewBufferString(c.json), &got)
+		var gotPaths []string
+		if verr, ok := err.(ValidationError); ok {
+			for _, e := range verr {
+				gotPaths = append(gotPaths, e.Path)
+			}
+		} else if err != nil {
+			t.Fatalf("Case %d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(gotPaths, c.paths) {
+			t.Errorf("Case %d: got error paths %v, want %v", i, gotPaths, c.paths)
+		} else if c.paths == nil && !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Case %d: got %+v, want %+v", i, got, c.want)
+		}
+	}
+
+	// and the root value
+	got := &age
+	if err := Parser(&got, Nullable(Integer())).Parse(bytes.NewBufferString(`null`), &got); err != nil {
+		t.Fatal(err)
+	} else if got != nil {
+		t.Fatalf("Got %v, want nil", *got)
+	}
+}
+
+func Test_Duration(t *testing.T) {
+	type config struct {
+		Timeout time.Duration
+	}
+	schema := Struct(Prop("Timeout", Duration(MinDuration(time.Second), MaxDuration(time.Hour))))
+
+	cases := []struct {
+		json string
+		want time.Duration
+		msg  string
+	}{
+		{`{"Timeout": "1500ms"}`, 1500 * time.Millisecond, ""},
+		{`{"Timeout": "45m30s"}`, 45*time.Minute + 30*time.Second, ""},
+		{`{"Timeout": "2h45m"}`, 0, "Must be less than or equal to 1h0m0s"},
+		{`{"Timeout": "10ms"}`, 0, "Must be greater than or equal to 1s"},
+		{`{"Timeout": "soon"}`, 0, fmt.Sprintf(ERROR_INVALID_GO_DURATION, "soon")},
+		{`{"Timeout": 1500}`, 0, fmt.Sprintf(ERROR_INVALID_DURATION, "1500")},
+	}
+
+	for i, c := range cases {
+		var got config
+		err := Parser(&got, schema).Parse(bytes.NewBufferString(c.json), &got)
+		if c.msg == "" {
+			if err != nil {
+				t.Errorf("Case %d: unexpected error %v", i, err)
+			} else if got.Timeout != c.want {
+				t.Errorf("Case %d: got %v, want %v", i, got.Timeout, c.want)
+			}
+		} else if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/Timeout" || verr[0].Error != c.msg {
+			t.Errorf("Case %d: got error %v, want %q at /Timeout", i, err, c.msg)
+		}
+	}
+
+	// other int64 types aren't durations
+	if err := Duration().Prepare(reflect.TypeOf(int64(0))); err == nil {
+		t.Errorf("Got no error preparing for an int64, wanted one")
+	}
+}
+
+func Test_ISODuration(t *testing.T) {
+	cases := []struct {
+		json string
+		want time.Duration
+	}{
+		{`"PT1H30M"`, 90 * time.Minute},
+		{`"P1DT2H"`, 26 * time.Hour},
+		{`"P2W"`, 14 * 24 * time.Hour},
+		{`"P1D"`, 24 * time.Hour},
+		{`"PT45S"`, 45 * time.Second},
+		{`"PT1.5S"`, 1500 * time.Millisecond},
+		{`"PT0,25H"`, 15 * time.Minute},
+		{`"P1DT1H1M1S"`, 25*time.Hour + time.Minute + time.Second},
+		{`"PT0S"`, 0},
+		{`"-PT10M"`, -10 * time.Minute},
+	}
+
+	for i, c := range cases {
+		var got time.Duration
+		if err := tryParse(ISODuration(), c.json, &got, c.want); err != nil {
+			t.Errorf("Case %d (%v): %v", i, c.json, err)
+		}
+	}
+
+	bad := []struct {
+		json string
+		msg  string
+	}{
+		{`"P1Y"`, ERROR_ISO_DURATION_YEARS},
+		{`"P2M"`, ERROR_ISO_DURATION_YEARS},
+		{`"P1Y2DT3H"`, ERROR_ISO_DURATION_YEARS},
+		{`"1h30m"`, fmt.Sprintf(ERROR_INVALID_ISO_DURATION, "1h30m")},
+		{`"P"`, fmt.Sprintf(ERROR_INVALID_ISO_DURATION, "P")},
+		{`"PT"`, fmt.Sprintf(ERROR_INVALID_ISO_DURATION, "PT")},
+		{`"P1DT"`, fmt.Sprintf(ERROR_INVALID_ISO_DURATION, "P1DT")},
+		{`"PT30M1H"`, fmt.Sprintf(ERROR_INVALID_ISO_DURATION, "PT30M1H")},
+		{`"PT1.5H30M"`, fmt.Sprintf(ERROR_INVALID_ISO_DURATION, "PT1.5H30M")},
+		{`"PT1H2"`, fmt.Sprintf(ERROR_INVALID_ISO_DURATION, "PT1H2")},
+		{`"P99999999999999W"`, fmt.Sprintf(ERROR_INVALID_ISO_DURATION, "P99999999999999W")},
+		{`90`, fmt.Sprintf(ERROR_INVALID_DURATION, "90")},
+	}
+
+	for i, c := range bad {
+		var got time.Duration
+		err := tryParse(ISODuration(), c.json, &got, got)
+		if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Error != c.msg {
+			t.Errorf("Case %d (%v): got error %v, want %q", i, c.json, err, c.msg)
+		}
+	}
+}
+
+func Test_DateFormat(t *testing.T) {
+	cases := []struct {
+		s    SchemaType
+		json string
+		want time.Time
+	}{
+		{DateFormat("02/01/2006"), `"25/12/2021"`, mkDate(2021, 12, 25)},
+		{DateFormat("Jan 2, 2006"), `"Mar 7, 2020"`, mkDate(2020, 3, 7)},
+		{DateFormat("2006-01-02"), `"2015-05-21"`, mkDate(2015, 5, 21)},
+	}
+
+	for i, c := range cases {
+		var got time.Time
+		if err := tryParse(c.s, c.json, &got, c.want); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		}
+	}
+
+	// input that doesn't match is just invalid
+	var got time.Time
+	err := tryParse(DateFormat("02/01/2006"), `"2021-12-25"`, &got, got)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/" {
+		t.Fatalf("Got error %v, want one at \"/\"", err)
+	}
+}
+
+func Test_DateTimeRFC3339(t *testing.T) {
+	cases := []struct {
+		json string
+		want time.Time
+	}{
+		{`"2016-03-10T23:00:00.000Z"`, time.Date(2016, 3, 10, 23, 0, 0, 0, time.UTC)},
+		{`"2016-03-10T23:00:00Z"`, time.Date(2016, 3, 10, 23, 0, 0, 0, time.UTC)},
+		{`"2016-03-10T23:00:00.25Z"`, time.Date(2016, 3, 10, 23, 0, 0, 250000000, time.UTC)},
+		{`"2016-03-10T23:00:00+10:00"`, time.Date(2016, 3, 10, 13, 0, 0, 0, time.UTC)},
+	}
+
+	for i, c := range cases {
+		var got time.Time
+		s := NewScanner(bytes.NewBufferString(c.json))
+		if err := DateTime().Parse(func() string { return "/" }, s, &got); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		} else if !got.Equal(c.want) {
+			t.Errorf("Case %d: got %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func Test_DateTimeInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC-4", -4*60*60)
+	schema := DateTimeInLocation("2006-01-02 15:04:05", loc)
+
+	var got time.Time
+	s := NewScanner(bytes.NewBufferString(`"2021-06-01 09:00:00"`))
+	if err := schema.Parse(func() string { return "/" }, s, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(2021, 6, 1, 13, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("Got instant %v, want %v", got.UTC(), want)
+	} else if got.Location() != loc {
+		t.Fatalf("Got location %v, want %v", got.Location(), loc)
+	}
+}
+
+func Test_FlexibleTime(t *testing.T) {
+	want := time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC)
+	cases := []struct {
+		schema  *FlexibleTimeParser
+		json    string
+		wantErr bool
+	}{
+		{FlexibleTime(), `"2021-06-01 09:00:00"`, false},
+		{FlexibleTime(), `1622538000`, false},
+		{FlexibleTime().UnixUnit(time.Millisecond), `1622538000000`, false},
+		{FlexibleTime().UnixUnit(time.Hour), `450705`, false},
+		{FlexibleTime().Layout("2006-01-02T15:04:05Z07:00", time.UTC), `"2021-06-01T09:00:00Z"`, false},
+		{FlexibleTime(), `1622538000.5`, true},
+		{FlexibleTime(), `"1622538000"`, true},
+		{FlexibleTime().UnixUnit(time.Hour), `9223372036854775807`, true},
+		{FlexibleTime(), `true`, true},
+	}
+
+	for i, c := range cases {
+		var got time.Time
+		s := NewScanner(bytes.NewBufferString(c.json))
+		err := c.schema.Parse(func() string { return "/" }, s, &got)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%d: Expected an error parsing %v, got %v", i, c.json, got)
+			}
+		} else if err != nil {
+			t.Errorf("%d: Unexpected error parsing %v: %v", i, c.json, err)
+		} else if !got.Equal(want) {
+			t.Errorf("%d: Got %v, want %v", i, got, want)
+		}
+	}
+
+	// a validator applies to both forms
+	notFuture := DateTimeValidatorFunc(func(t time.Time) error {
+		if t.After(want) {
+			return fmt.Errorf("Must not be after %v", want)
+		}
+		return nil
+	})
+	schema := FlexibleTime(notFuture)
+	for _, json := range []string{`"2021-06-01 09:00:01"`, `1622538001`} {
+		var got time.Time
+		s := NewScanner(bytes.NewBufferString(json))
+		if err := schema.Parse(func() string { return "/" }, s, &got); err == nil {
+			t.Errorf("Expected validation error for %v", json)
+		} else if _, ok := err.(ValidationError); !ok {
+			t.Errorf("Got %v for %v, want a ValidationError", err, json)
+		}
+	}
+
+	// options apply whatever order they're given in
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("No timezone data", err)
+	}
+	for i, schema := range []*FlexibleTimeParser{
+		FlexibleTime().EmptyAsAbsent().Layout("2006-01-02 15:04", nyc),
+		FlexibleTime().Layout("2006-01-02 15:04", nyc).EmptyAsAbsent(),
+	} {
+		got := time.Time{}
+		if err := schema.Parse(func() string { return "/" }, NewScanner(bytes.NewBufferString(`""`)), &got); err != ErrAbsent {
+			t.Errorf("%d: Got %v for an empty string, want ErrAbsent", i, err)
+		}
+		if err := schema.Parse(func() string { return "/" }, NewScanner(bytes.NewBufferString(`"2021-06-01 05:00"`)), &got); err != nil {
+			t.Errorf("%d: Unexpected error %v", i, err)
+		} else if !got.Equal(want) {
+			t.Errorf("%d: Got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func Test_RawBytesNoCopy(t *testing.T) {
+	type blob struct {
+		Data []byte
+	}
+	parser := Parser(&blob{}, Struct(Prop("Data", RawBytesNoCopy())))
+
+	// from a fixed buffer, the value should alias the input
+	input := []byte(`{"Data": "aGVsbG8="}`)
+	var aliased blob
+	if err := parser.ParseBytes(input, &aliased); err != nil {
+		t.Fatal(err)
+	} else if string(aliased.Data) != "aGVsbG8=" {
+		t.Fatalf("Got %s, want aGVsbG8=", aliased.Data)
+	} else if &aliased.Data[0] != &input[10] {
+		t.Fatalf("Value does not alias the input")
+	} else if cap(aliased.Data) != len(aliased.Data) {
+		t.Fatalf("Got cap %d, want %d", cap(aliased.Data), len(aliased.Data))
+	}
+
+	// from a stream, it must be a copy
+	var copied blob
+	if err := parser.Parse(bytes.NewReader(input), &copied); err != nil {
+		t.Fatal(err)
+	}
+	input[10] = 'Z'
+	if string(copied.Data) != "aGVsbG8=" {
+		t.Fatalf("Got %s, want aGVsbG8=", copied.Data)
+	} else if string(aliased.Data) != "ZGVsbG8=" {
+		t.Fatalf("Got %s, want ZGVsbG8=", aliased.Data)
+	}
+}
+
+func Test_StructFromMap(t *testing.T) {
+	type person struct {
+		Name    string
+		Age     int64
+		Friends []string
+	}
+
+	schema := StructFromMap(map[string]SchemaType{
+		"Name":    String(MinLen(1)),
+		"Friends": Slice(String()),
+	}).AddPropWithDefault("Age", Integer(), int64(-1))
+
+	want := person{"Angelo", -1, []string{"Bob"}}
+	var got person
+	if err := tryParse(schema, `{"Name": "Angelo", "Friends": ["Bob"]}`, &got, want); err != nil {
+		t.Fatal(err)
+	}
+
+	// missing props should be reported in name order
+	err := tryParse(schema, `{}`, &got, want)
+	if verr, ok := err.(ValidationError); !ok {
+		t.Fatalf("Got %v, want a ValidationError", err)
+	} else if len(verr) != 2 || verr[0].Path != "/Friends" || verr[1].Path != "/Name" {
+		t.Fatalf("Got %v, want errors for /Friends and /Name", verr)
+	}
+}
+
+func Test_ExpectedType(t *testing.T) {
+	cases := []struct {
+		s    SchemaType
+		want JSONType
+	}{
+		{Boolean(), JSONBoolean},
+		{Bytes(), JSONString},
+		{RawBytes(), JSONString},
+		{RawBytesNoCopy(), JSONString},
+		{Base64Bytes(), JSONString},
+		{Date(), JSONString},
+		{DateTime(), JSONString},
+		{FlexibleTime(), JSONAny},
+		{Duration(), JSONString},
+		{ISODuration(), JSONString},
+		{Null(), JSONNull},
+		{RawJSON(), JSONAny},
+		{Nullable(Integer()), JSONNumber},
+		{Enum(Integer(), int64(1)), JSONNumber},
+		{Enum(String(), "a"), JSONString},
+		{Integer(), JSONNumber},
+		{Float(), JSONNumber},
+		{Slice(Integer()), JSONArray},
+		{Map(Integer()), JSONObject},
+		{Pairs(Integer()), JSONObject},
+		{OneOf("type", nil), JSONObject},
+		{Any(), JSONAny},
+		{OneOrMany(Integer()), JSONAny},
+		{String(), JSONString},
+		{Struct(), JSONObject},
+		{StructPositional(), JSONArray},
+		{Unmarshaler(), JSONAny},
+		{Auto(), JSONAny},
+	}
+
+	for i, c := range cases {
+		if got := ExpectedTypeOf(c.s); got != c.want {
+			t.Errorf("Case %d: Got %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func Test_EmptyAsAbsent(t *testing.T) {
+	type form struct {
+		Age      *int64
+		Count    int64
+		Birthday *time.Time
+	}
+
+	schema := Struct(
+		Prop("Age", Integer().EmptyAsAbsent()),
+		PropWithDefault("Count", Integer().EmptyAsAbsent(), int64(1)),
+		Prop("Birthday", Date().EmptyAsAbsent()),
+	)
+
+	var got form
+	if err := tryParse(schema, `{"Age": "", "Count": "", "Birthday": ""}`, &got, form{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// without the option, empty strings are still errors
+	strict := Struct(Prop("Age", Integer()))
+	if err := tryParse(strict, `{"Age": ""}`, &form{}, form{}); err == nil {
+		t.Fatal("Got no error, wanted one")
+	}
+
+	// array elements can't be absent
+	err := tryParse(Slice(Integer().EmptyAsAbsent()), `[1, ""]`, new([]int64), []int64{1, 0})
+	if verr, ok := err.(ValidationError); !ok {
+		t.Fatalf("Got %v, want a ValidationError", err)
+	} else if len(verr) != 1 || verr[0].Path != "/1/" {
+		t.Fatalf("Got %v, want an error at /1/", verr)
+	}
+}
+
+func Test_PropTransform(t *testing.T) {
+	upper := func(v reflect.Value) error {
+		v.SetString(strings.ToUpper(v.String()))
+		return nil
+	}
+	noBobs := func(v reflect.Value) error {
+		if v.String() == "Bob" {
+			return fmt.Errorf("No Bobs")
+		}
+		return nil
+	}
+
+	schema := Struct(
+		PropTransform("Captcha", String(), upper),
+		PropTransform("Fullname", String(), noBobs),
+	)
+
+	var got simpleStruct
+	if err := tryParse(schema, `{"Captcha": "zing", "Fullname": "Jim"}`, &got, simpleStruct{"ZING", "Jim"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := tryParse(schema, `{"Captcha": "zing", "Fullname": "Bob"}`, &got, got)
+	if verr, ok := err.(ValidationError); !ok {
+		t.Fatalf("Got %v, want a ValidationError", err)
+	} else if len(verr) != 1 || verr[0].Path != "/Fullname" || verr[0].Error != "No Bobs" {
+		t.Fatalf("Got %v, want No Bobs at /Fullname", verr)
+	}
+}
+
+func Test_IntegerAllowWholeFloat(t *testing.T) {
+	cases := []struct {
+		json    string
+		dest    interface{}
+		want    interface{}
+		isValid bool
+	}{
+		{"24", new(int64), int64(24), true},
+		{"24.0", new(int64), int64(24), true},
+		{"2.4e1", new(int64), int64(24), true},
+		{"-2.4E+1", new(int64), int64(-24), true},
+		{"1e3", new(int64), int64(1000), true},
+		{"5.0", new(int32), int32(5), true},
+		{"24.5", new(int64), nil, false},
+		{"5.5", new(int64), nil, false},
+		{"2.45e1", new(int64), nil, false},
+		{"127.0", new(int8), int8(127), true},
+		{"128.0", new(int8), nil, false},
+		{"255.0", new(uint8), uint8(255), true},
+		{"-1.0", new(uint8), nil, false},
+
+		// around 2^53, where float64 can no longer hold every whole number
+		{"9007199254740991.0", new(int64), int64(9007199254740991), true},
+		{"-9007199254740991.0", new(int64), int64(-9007199254740991), true},
+		{"9007199254740992.0", new(int64), nil, false},
+		{"9007199254740993.0", new(int64), nil, false},
+		{"-9007199254740992.0", new(int64), nil, false},
+		{"9.007199400000001e15", new(int64), nil, false},
+		{"1e300", new(int64), nil, false},
+
+		// non-float integers are unaffected
+		{"9007199254740993", new(int64), int64(9007199254740993), true},
+	}
+
+	for i, c := range cases {
+		want := c.want
+		if want == nil {
+			want = c.dest
+		}
+		err := tryParse(Integer().AllowWholeFloat(), c.json, c.dest, want)
+		if c.isValid && err != nil {
+			t.Errorf("Case %d: Got error %v, want nil", i, err)
+		} else if !c.isValid {
+			if _, ok := err.(ValidationError); !ok {
+				t.Errorf("Case %d: Got %v, want a ValidationError", i, err)
+			}
+		}
+	}
+
+	// without the option, floats are still errors
+	if err := tryParse(Integer(), "24.0", new(int64), int64(24)); err == nil {
+		t.Errorf("Got no error, wanted one")
+	}
+}
+
+func Test_StructOnUnknownField(t *testing.T) {
+	var got []string
+	schema := Struct(Prop("Captcha", String())).OnUnknownField(func(path, name string) {
+		got = append(got, path+name)
+	})
+
+	json := `{"Captcha": "Zing", "Fullname": {"a": [1, 2]}, "Extra!": true}`
+	if err := tryParse(schema, json, new(simpleStruct), simpleStruct{Captcha: "Zing"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"/Fullname", "/Extra!"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Got %v, want %v", got, want)
+	}
+}
+
+func Test_StructStrict(t *testing.T) {
+	schema := Struct(Prop("Captcha", String()), Prop("Fullname", String(MinLen(2)))).Strict()
+
+	json := `{"Captcha": "a", "Admin": true, "Fullname": "b", "Role": {"x": [1]}}`
+	var got simpleStruct
+	err := Parser(&got, schema).Parse(bytes.NewBufferString(json), &got)
+	verr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Got %v, want a ValidationError", err)
+	}
+
+	var gotPaths []string
+	for _, e := range verr {
+		gotPaths = append(gotPaths, e.Path)
+	}
+	if want := []string{"/Admin", "/Fullname", "/Role"}; !reflect.DeepEqual(gotPaths, want) {
+		t.Errorf("Got error paths %v, want %v", gotPaths, want)
+	} else if verr[0].Error != ERROR_PROP_UNKNOWN {
+		t.Errorf("Got %q, want %q", verr[0].Error, ERROR_PROP_UNKNOWN)
+	}
+
+	// optional props can still be left out
+	type optional struct {
+		Captcha  string
+		Fullname *string
+	}
+	if err := tryParse(schema, `{"Captcha": "a"}`, new(optional), optional{Captcha: "a"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_StructExact(t *testing.T) {
+	type account struct {
+		Name     string
+		Nickname *string
+		Age      int64
+	}
+	schema := Struct(
+		Prop("Name", String()),
+		Prop("Nickname", String()),
+		PropWithDefault("Age", Integer(), int64(18)),
+	).Exact()
+
+	cases := []struct {
+		json      string
+		wantPaths []string
+	}{
+		{`{"Name": "Zing", "Nickname": "Z", "Age": 3}`, nil},
+		{`{"Name": "Zing", "Age": 3, "Admin": true}`, []string{"/Admin", "/Nickname"}},
+		{`{"Name": "Zing", "Nickname": "Z"}`, []string{"/Age"}},
+		{`{}`, []string{"/Name", "/Nickname", "/Age"}},
+	}
+
+	for i, c := range cases {
+		var got account
+		err := Parser(&got, schema).Parse(bytes.NewBufferString(c.json), &got)
+		var gotPaths []string
+		if verr, ok := err.(ValidationError); ok {
+			for _, e := range verr {
+				gotPaths = append(gotPaths, e.Path)
+			}
+		} else if err != nil {
+			t.Fatalf("Case %d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(gotPaths, c.wantPaths) {
+			t.Errorf("Case %d: got error paths %v, want %v", i, gotPaths, c.wantPaths)
+		}
+	}
+}
+
+func Test_StructRejectDuplicateKeys(t *testing.T) {
+	schema := Struct(Prop("Captcha", String()), Prop("Fullname", String())).RejectDuplicateKeys()
+
+	cases := []struct {
+		json  string
+		want  simpleStruct
+		paths []string
+	}{
+		{`{"Captcha": "a", "Fullname": "b", "Extra": 1}`, simpleStruct{"a", "b"}, nil},
+		{`{"Captcha": "a", "Fullname": "b", "Captcha": "c"}`, simpleStruct{"a", "b"}, []string{"/Captcha"}},
+		{`{"Captcha": "a", "Extra": 1, "Fullname": "b", "Extra": {"x": [2]}}`, simpleStruct{"a", "b"}, []string{"/Extra"}},
+		{`{"Captcha": "a", "Captcha": 1, "Captcha": "c"}`, simpleStruct{Captcha: "a"}, []string{"/Captcha", "/Captcha", "/Fullname"}},
+	}
+
+	for i, c := range cases {
+		var got simpleStruct
+		err := Parser(&got, schema).Parse(bytes.NewBufferString(c.json), &got)
+		var gotPaths []string
+		if verr, ok := err.(ValidationError); ok {
+			for _, e := range verr {
+				gotPaths = append(gotPaths, e.Path)
+			}
+		} else if err != nil {
+			t.Fatalf("Case %d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(gotPaths, c.paths) {
+			t.Errorf("Case %d: got error paths %v, want %v", i, gotPaths, c.paths)
+		} else if got != c.want {
+			t.Errorf("Case %d: got %+v, want %+v", i, got, c.want)
+		}
+	}
+
+	// without the option the last one wins, as before
+	var got simpleStruct
+	json := `{"Captcha": "a", "Fullname": "b", "Captcha": "c"}`
+	if err := tryParse(Struct(Prop("Captcha", String()), Prop("Fullname", String())), json, &got, simpleStruct{"c", "b"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_TrailingComma(t *testing.T) {
+	type listStruct struct {
+		Captcha string
+		List    []int64
+	}
+	schema := Struct(Prop("Captcha", String()), Prop("List", Slice(Integer())))
+	want := listStruct{"Zing", []int64{1, 2}}
+
+	cases := []struct {
+		json    string
+		isValid bool // when trailing commas aren't allowed
+	}{
+		{`{"Captcha": "Zing", "List": [1, 2]}`, true},
+		{`{"Captcha": "Zing", "List": [1, 2], }`, false},
+		{`{"Captcha": "Zing", "List": [1, 2,]}`, false},
+		{`{"Captcha": "Zing", "List": [1, 2,], }`, false},
+		// unknown fields are skipped by the scanner, which must agree
+		{`{"Captcha": "Zing", "Other": [3,], "List": [1, 2]}`, false},
+		{`{"Captcha": "Zing", "Other": {"a": 1,}, "List": [1, 2]}`, false},
+	}
+
+	for i, c := range cases {
+		for _, allow := range []bool{false, true} {
+			var got listStruct
+			parser := Parser(&got, schema).AllowTrailingComma(allow)
+			err := parser.Parse(bytes.NewBufferString(c.json), &got)
+
+			if (c.isValid || allow) && err != nil {
+				t.Errorf("Case %d, allowed %v: Got error %v, want nil", i, allow, err)
+			} else if !c.isValid && !allow && err == nil {
+				t.Errorf("Case %d, allowed %v: Got no error, wanted one", i, allow)
+			} else if err == nil && !reflect.DeepEqual(got, want) {
+				t.Errorf("Case %d, allowed %v: Got %v, want %v", i, allow, got, want)
+			}
+		}
+	}
+
+	// a lone ',' is never valid
+	for _, json := range []string{`[,]`, `{,}`} {
+		s := NewScanner(bytes.NewBufferString(json))
+		s.AllowTrailingComma(true)
+		if err := s.SkipValue(); err == nil {
+			t.Errorf("%s: Got no error, wanted one", json)
+		}
+	}
+
+	// parsed arrays report it like objects do, not as a missing element
+	type pair struct {
+		A, B int64
+	}
+	msgCases := []struct {
+		s    SchemaType
+		json string
+		dest interface{}
+	}{
+		{Slice(Integer()), `[1,]`, new([]int64)},
+		{Slice(Integer()), `[1, 2 , ]`, new([]int64)},
+		{Slice(Slice(Integer())), `[[1],]`, new([][]int64)},
+		{Slice(Integer()), `[1,]`, new([2]int64)},
+		{StructPositional(Prop("A", Integer()), Prop("B", Integer())), `[1,]`, new(pair)},
+		{StructPositional(Prop("A", Integer())), `[1, 2,]`, new(pair)},
+	}
+	for i, c := range msgCases {
+		err := Parser(c.dest, c.s).Parse(bytes.NewBufferString(c.json), c.dest)
+		if perr, ok := err.(*ParseError); !ok || perr.Error() != ERROR_TRAILING_COMMA_ARR {
+			t.Errorf("Case %d: Got %v, want %v", i, err, ERROR_TRAILING_COMMA_ARR)
+		}
+	}
+}
+
+func Test_LeadingPlus(t *testing.T) {
+	type point struct {
+		X int64
+		Y float64
+	}
+	schema := Struct(Prop("X", Integer()), Prop("Y", Float()))
+	want := point{5, 1.5}
+
+	cases := []struct {
+		json    string
+		isValid bool // when a leading '+' isn't allowed
+	}{
+		{`{"X": 5, "Y": 1.5}`, true},
+		{`{"X": +5, "Y": 1.5}`, false},
+		{`{"X": 5, "Y": +1.5}`, false},
+		{`{"X": +5, "Y": +15e-1}`, false},
+		// unknown fields are skipped by the scanner, which must agree
+		{`{"X": 5, "Other": [+1], "Y": 1.5}`, false},
+	}
+
+	for i, c := range cases {
+		for _, allow := range []bool{false, true} {
+			var got point
+			parser := Parser(&got, schema).AllowLeadingPlus(allow)
+			err := parser.Parse(bytes.NewBufferString(c.json), &got)
+
+			if (c.isValid || allow) && err != nil {
+				t.Errorf("Case %d, allowed %v: Got error %v, want nil", i, allow, err)
+			} else if !c.isValid && !allow && err == nil {
+				t.Errorf("Case %d, allowed %v: Got no error, wanted one", i, allow)
+			} else if err == nil && !reflect.DeepEqual(got, want) {
+				t.Errorf("Case %d, allowed %v: Got %v, want %v", i, allow, got, want)
+			}
+		}
+	}
+
+	// the '+' isn't part of the token, and is only allowed on a number
+	s := NewScanner(bytes.NewBufferString(`+5`))
+	s.AllowLeadingPlus(true)
+	if tok, buf, err := s.ReadToken(); tok != TokenNumber || string(buf) != "5" || err != nil {
+		t.Errorf("Got %v %q %v, want the number 5", tok, buf, err)
+	}
+	for _, json := range []string{`+`, `+-5`, `++5`, `+ 5`, `+"5"`, `+true`} {
+		s := NewScanner(bytes.NewBufferString(json))
+		s.AllowLeadingPlus(true)
+		if err := s.SkipValue(); err == nil {
+			t.Errorf("%s: Got no error, wanted one", json)
+		}
+	}
+}
+
+func Test_StructPositional(t *testing.T) {
+	type person struct {
+		Name    string
+		Age     int64
+		Friends []string
+	}
+
+	schema := StructPositional(
+		Prop("Name", String()),
+		Prop("Age", Integer(MinI(0))),
+		PropWithDefault("Friends", Slice(String()), []string{}),
+	)
+
+	cases := []struct {
+		json string
+		want person
+	}{
+		{`["Angelo", 24, ["Bob"]]`, person{"Angelo", 24, []string{"Bob"}}},
+		{`["Angelo", 24]`, person{"Angelo", 24, []string{}}},
+	}
+	for i, c := range cases {
+		if err := tryParse(schema, c.json, new(person), c.want); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		}
+	}
+
+	errCases := []struct {
+		json      string
+		wantPaths []string
+	}{
+		{`[]`, []string{"/Name", "/Age"}},
+		{`["Angelo"]`, []string{"/Age"}},
+		{`["Angelo", -1]`, []string{"/Age"}},
+		{`["Angelo", 24, [], {"extra": true}]`, []string{"/"}},
+	}
+	for i, c := range errCases {
+		err := tryParse(schema, c.json, new(person), person{})
+		verr, ok := err.(ValidationError)
+		if !ok {
+			t.Errorf("Error case %d: Got %v, want a ValidationError", i, err)
+			continue
+		}
+
+		gotPaths := make([]string, len(verr))
+		for i, e := range verr {
+			gotPaths[i] = e.Path
+		}
+		if !reflect.DeepEqual(gotPaths, c.wantPaths) {
+			t.Errorf("Error case %d: Got paths %v, want %v", i, gotPaths, c.wantPaths)
+		}
+	}
+
+	// extra elements are counted, not reported as too many items
+	err := tryParse(schema, `["Angelo", 24, [], 1, 2]`, new(person), person{})
+	if want := (ValidationError{{Path: "/", Error: "Expected at most 3 items, got 5"}}); !reflect.DeepEqual(err, want) {
+		t.Errorf("Got %v, want %v", err, want)
+	}
+
+	// must be an array
+	if err := tryParse(schema, `{"Name": "Angelo"}`, new(person), person{}); err == nil {
+		t.Errorf("Got no error for an object, wanted one")
+	}
+}
+
+func Test_EnumMessage(t *testing.T) {
+	many := make([]interface{}, 25)
+	for i := range many {
+		many[i] = int64(i)
+	}
+
+	cases := []struct {
+		t    SchemaType
+		json string
+		dest interface{}
+		want string
+	}{
+		{Enum(String(), "a", "b", "c"), `"x"`, new(string), `"x" is not allowed; expected one of: "a", "b", "c"`},
+		{Enum(Integer(), int64(1), int64(2)), `3`, new(int64), `3 is not allowed; expected one of: 1, 2`},
+		{Enum(Integer(), many...), `30`, new(int64), `30 is not allowed; expected one of: 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, … and 15 more`},
+		{Enum(String(), "on", enumStatus("off")), `"x"`, new(string), `"x" is not allowed; expected one of: "on", "off"`},
+		{Enum(Struct(Prop("Code", String())), enumCurrency{"AUD"}, enumCurrency{"NZD"}), `{"Code": "USD"}`, new(enumCurrency),
+			`{"Code":"USD"} is not allowed; expected one of: {"Code":"AUD"}, {"Code":"NZD"}`},
+	}
+
+	for i, c := range cases {
+		err := tryParse(c.t, c.json, c.dest, c.dest)
+		if verr, ok := err.(ValidationError); !ok {
+			t.Errorf("Case %d: Got %v, want a ValidationError", i, err)
+		} else if verr[0].Error != c.want {
+			t.Errorf("Case %d: Got %q, want %q", i, verr[0].Error, c.want)
+		}
+	}
+
+	// building a large enum shouldn't build its message
+	allocs := testing.AllocsPerRun(10, func() {
+		Enum(Integer(), many...)
+	})
+	if allocs > 2 {
+		t.Errorf("Got %v allocations building an Enum, want no more than 2", allocs)
+	}
+}
+
+type enumStatus string
+
+type enumCurrency struct {
+	Code string
+}
+
+type enumColor int
+
+const (
+	enumRed enumColor = iota
+	enumGreen
+	enumBlue
+)
+
+func Test_EnumNamed(t *testing.T) {
+	names := map[int64]string{int64(enumBlue): "Blue", int64(enumRed): "Red", int64(enumGreen): "Green"}
+
+	// valid values are stored as numbers
+	for _, want := range []enumColor{enumRed, enumGreen, enumBlue} {
+		if err := tryParse(EnumNamed(Integer(), names), strconv.Itoa(int(want)), new(enumColor), want); err != nil {
+			t.Errorf("%v: %v", want, err)
+		}
+	}
+	if err := tryParse(EnumNamed(Integer(), names), `2`, new(int64), int64(2)); err != nil {
+		t.Error(err)
+	}
+	if err := tryParse(EnumNamed(Integer(), names), `1`, new(uint8), uint8(1)); err != nil {
+		t.Error(err)
+	}
+
+	// invalid ones list the names
+	for _, json := range []string{`3`, `-1`} {
+		err := tryParse(EnumNamed(Integer(), names), json, new(enumColor), enumColor(0))
+		want := json + " is not allowed; expected one of: Red, Green, Blue"
+		if verr, ok := err.(ValidationError); !ok {
+			t.Errorf("%v: Got %v, want a ValidationError", json, err)
+		} else if verr[0].Error != want {
+			t.Errorf("%v: Got %q, want %q", json, verr[0].Error, want)
+		}
+	}
+
+	// the values must suit the destination
+	if err := EnumNamed(Integer(), names).Prepare(reflect.TypeOf("")); err == nil {
+		t.Errorf("Got no error preparing for a string, want one")
+	}
+}
+
+func Test_EnumFoldCase(t *testing.T) {
+	cases := []struct {
+		t    SchemaType
+		json string
+		dest interface{}
+		want interface{}
+		ok   bool
+	}{
+		{Enum(String(), "active", "inactive").FoldCase(), `"ACTIVE"`, new(string), "active", true},
+		{Enum(String(), "active", "inactive").FoldCase(), `"InActive"`, new(string), "inactive", true},
+		{Enum(String(), "active", "inactive").FoldCase(), `"active"`, new(string), "active", true},
+		{Enum(String(), "active", "inactive").FoldCase(), `"deleted"`, new(string), "", false},
+		{Enum(String(), "active", "inactive"), `"ACTIVE"`, new(string), "", false},
+		{Enum(Integer(), int64(1), int64(2)).FoldCase(), `2`, new(int64), int64(2), true},
+		{Enum(Integer(), int64(1), int64(2)).FoldCase(), `3`, new(int64), int64(0), false},
+		{Enum(String(), enumStatus("Active")).FoldCase(), `"aCTIVE"`, new(string), "Active", true},
+		{Struct(Prop("Country", Enum(String(), "AU", "NZ").FoldCase())), `{"Country": "nz"}`, new(struct{ Country string }), struct{ Country string }{"NZ"}, true},
+	}
+
+	for i, c := range cases {
+		err := tryParse(c.t, c.json, c.dest, c.want)
+		if _, isVerr := err.(ValidationError); !c.ok && !isVerr {
+			t.Errorf("Case %d: Got %v, want a ValidationError", i, err)
+		} else if c.ok && err != nil {
+			t.Errorf("Case %d: Unexpected error %v", i, err)
+		}
+	}
+
+	// the message lists the allowed values as given
+	err := tryParse(Enum(String(), "Active", "Inactive").FoldCase(), `"deleted"`, new(string), "")
+	if verr, ok := err.(ValidationError); !ok {
+		t.Fatalf("Got %v, want a ValidationError", err)
+	} else if want := `"deleted" is not allowed; expected one of: "Active", "Inactive"`; verr[0].Error != want {
+		t.Fatalf("Got %q, want %q", verr[0].Error, want)
+	}
+}
+
+func Test_EnumWithEqual(t *testing.T) {
+	type currency struct {
+		Code string
+		Name string
+		Tags []string // makes it incomparable
+	}
+	byCode := func(allowed, got interface{}) bool {
+		return allowed.(currency).Code == got.(currency).Code
+	}
+	schema := func() SchemaType {
+		return Enum(
+			Struct(Prop("Code", String()), Prop("Name", String()), Prop("Tags", Slice(String()))),
+			currency{"AUD", "Australian dollar", nil},
+			currency{"NZD", "New Zealand dollar", nil},
+		).WithEqual(byCode)
+	}
+
+	// only the code matters, the rest is kept as sent
+	json := `{"Code": "NZD", "Name": "Kiwi", "Tags": ["nz"]}`
+	if err := tryParse(schema(), json, new(currency), currency{"NZD", "Kiwi", []string{"nz"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := tryParse(schema(), `{"Code": "USD", "Name": "Australian dollar", "Tags": []}`, new(currency), currency{})
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/" {
+		t.Fatalf("Got %v, want a ValidationError at /", err)
+	}
+
+	// it replaces FoldCase, so case matters unless fn says otherwise
+	strict := Enum(String(), "active").FoldCase().WithEqual(func(allowed, got interface{}) bool { return allowed == got })
+	if _, ok := tryParse(strict, `"ACTIVE"`, new(string), "").(ValidationError); !ok {
+		t.Fatalf("Got no ValidationError with FoldCase and WithEqual")
+	}
+	loose := Enum(String(), "active").WithEqual(func(allowed, got interface{}) bool { return strings.EqualFold(allowed.(string), got.(string)) })
+	if err := tryParse(loose, `"ACTIVE"`, new(string), "ACTIVE"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_StructPropGroups(t *testing.T) {
+	type login struct {
+		Email    *string
+		Phone    *string
+		Password string
+	}
+
+	atLeast := Struct(
+		Prop("Email", String()),
+		Prop("Phone", String()),
+		Prop("Password", String()),
+	).AtLeastOneOf("Email", "Phone")
+	exactly := Struct(
+		Prop("Email", String()),
+		Prop("Phone", String()),
+		Prop("Password", String()),
+	).ExactlyOneOf("Email", "Phone")
+
+	cases := []struct {
+		s         SchemaType
+		json      string
+		wantPaths []string
+	}{
+		{atLeast, `{"Password": "x"}`, []string{"/"}},
+		{atLeast, `{"Password": "x", "Email": "a@b"}`, nil},
+		{atLeast, `{"Password": "x", "Phone": "123"}`, nil},
+		{atLeast, `{"Password": "x", "Email": "a@b", "Phone": "123"}`, nil},
+		{exactly, `{"Password": "x"}`, []string{"/"}},
+		{exactly, `{"Password": "x", "Email": "a@b"}`, nil},
+		{exactly, `{"Password": "x", "Email": "a@b", "Phone": "123"}`, []string{"/"}},
+		{exactly, `{}`, []string{"/Password", "/"}},
+	}
+
+	for i, c := range cases {
+		var got login
+		err := Parser(&got, c.s).Parse(bytes.NewBufferString(c.json), &got)
+		var gotPaths []string
+		if verr, ok := err.(ValidationError); ok {
+			for _, e := range verr {
+				gotPaths = append(gotPaths, e.Path)
+			}
+		} else if err != nil {
+			t.Errorf("Case %d: Got error %v", i, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(gotPaths, c.wantPaths) {
+			t.Errorf("Case %d: Got paths %v, want %v", i, gotPaths, c.wantPaths)
+		}
+	}
+
+	// groups must name real props
+	bad := Struct(Prop("Email", String())).AtLeastOneOf("Email", "Phone")
+	if _, err := ParserError(&login{}, bad); err == nil {
+		t.Errorf("Got no error for unknown group prop")
+	}
+}
+
+func Test_OneOrMany(t *testing.T) {
+	type order struct {
+		Items []simpleStruct
+	}
+	parser := Parser(&order{}, Struct(Prop("Items", OneOrMany(Struct(
+		Prop("Captcha", String(MinLen(1))),
+		Prop("Fullname", String()),
+	), MaxItems(2)))))
+
+	cases := []struct {
+		json      string
+		want      []simpleStruct
+		wantPaths []string
+	}{
+		{`{"Items": {"Captcha": "a", "Fullname": "b"}}`, []simpleStruct{{"a", "b"}}, nil},
+		{`{"Items": [{"Captcha": "a", "Fullname": "b"}]}`, []simpleStruct{{"a", "b"}}, nil},
+		{`{"Items": [{"Captcha": "a", "Fullname": "b"}, {"Captcha": "c", "Fullname": "d"}]}`, []simpleStruct{{"a", "b"}, {"c", "d"}}, nil},
+		{`{"Items": []}`, []simpleStruct{}, nil},
+		{`{"Items": {"Captcha": "", "Fullname": "b"}}`, nil, []string{"/ItemsCaptcha"}},
+		{`{"Items": [{"Captcha": "a", "Fullname": "b"}, {"Captcha": "", "Fullname": "d"}]}`, nil, []string{"/Items1/Captcha"}},
+		{`{"Items": [{"Captcha": "a", "Fullname": "b"}, {"Captcha": "a", "Fullname": "b"}, {"Captcha": "a", "Fullname": "b"}]}`, nil, []string{"/Items"}},
+	}
+
+	for i, c := range cases {
+		// start with more items than we want, to check they're replaced
+		got := order{Items: []simpleStruct{{"x", "y"}, {"x", "y"}}}
+		err := parser.Parse(bytes.NewBufferString(c.json), &got)
+		var gotPaths []string
+		if verr, ok := err.(ValidationError); ok {
+			for _, e := range verr {
+				gotPaths = append(gotPaths, e.Path)
+			}
+		} else if err != nil {
+			t.Errorf("Case %d: Got error %v", i, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(gotPaths, c.wantPaths) {
+			t.Errorf("Case %d: Got paths %v, want %v", i, gotPaths, c.wantPaths)
+		} else if c.wantPaths == nil && !reflect.DeepEqual(got.Items, c.want) {
+			t.Errorf("Case %d: Got %v, want %v", i, got.Items, c.want)
+		}
+	}
+}
+
+func Test_BooleanStrings(t *testing.T) {
+	mapped := Boolean().WithTruthy("yes", "on", "1").WithFalsy("no", "off", "0")
+
+	cases := []struct {
+		s       *BooleanParser
+		json    string
+		want    bool
+		wantErr bool
+	}{
+		{mapped, `true`, true, false},
+		{mapped, `false`, false, false},
+		{mapped, `"yes"`, true, false},
+		{mapped, `"ON"`, true, false},
+		{mapped, `"1"`, true, false},
+		{mapped, `"No"`, false, false},
+		{mapped, `"off"`, false, false},
+		{mapped, `"0"`, false, false},
+		{mapped, `"maybe"`, false, true},
+		{mapped, `""`, false, true},
+		{mapped, `1`, false, true},
+		{Boolean(), `"yes"`, false, true},
+		{Boolean(), `"true"`, false, true},
+	}
+
+	for i, c := range cases {
+		got := !c.want
+		s := NewScanner(bytes.NewBufferString(c.json))
+		err := c.s.Parse(func() string { return "/" }, s, &got)
+		if c.wantErr {
+			if _, ok := err.(ValidationError); !ok {
+				t.Errorf("Case %d: Got %v for %v, want a ValidationError", i, err, c.json)
+			}
+		} else if err != nil {
+			t.Errorf("Case %d: Unexpected error for %v: %v", i, c.json, err)
+		} else if got != c.want {
+			t.Errorf("Case %d: Got %v for %v, want %v", i, got, c.json, c.want)
+		}
+	}
+
+	// strings get the canonical text
+	var str string
+	s := NewScanner(bytes.NewBufferString(`"Yes"`))
+	if err := mapped.Parse(func() string { return "/" }, s, &str); err != nil {
+		t.Fatal(err)
+	} else if str != "true" {
+		t.Fatalf("Got %q, want \"true\"", str)
+	}
+}
+
+func Test_StructDoublePointer(t *testing.T) {
+	type nullable struct {
+		Name **string
+		Age  **int64
+	}
+	parser := Parser(&nullable{}, Struct(Prop("Name", String()), Prop("Age", Integer().EmptyAsAbsent())))
+
+	var got nullable
+	if err := parser.Parse(bytes.NewBufferString(`{"Name": "Bob", "Age": ""}`), &got); err != nil {
+		t.Fatal(err)
+	} else if got.Name == nil || *got.Name == nil {
+		t.Fatalf("Got %v, want both levels allocated", got.Name)
+	} else if **got.Name != "Bob" {
+		t.Fatalf("Got %q, want \"Bob\"", **got.Name)
+	} else if got.Age != nil {
+		t.Fatalf("Got %v for an absent value, want nil", got.Age)
+	}
+}
+
+type celsius float64
+
+func Test_FloatNamedType(t *testing.T) {
+	type reading struct {
+		T celsius
+	}
+	if err := tryParse(Struct(Prop("T", Float())), `{"T": 1.5}`, new(reading), reading{1.5}); err != nil {
+		t.Errorf("Got error %v, want nil", err)
+	}
+	if err := tryParse(Float(MaxF(100)), `-40`, new(celsius), celsius(-40)); err != nil {
+		t.Errorf("Got error %v, want nil", err)
+	}
+	if err := tryParse(Float(MaxF(100)), `101`, new(celsius), celsius(0)); err == nil {
+		t.Errorf("Got no error, wanted one")
+	}
+}
+
+func Test_FloatNonFinite(t *testing.T) {
+	cases := []struct {
+		s       *FloatParser
+		json    string
+		dest    interface{}
+		want    float64
+		wantErr bool
+	}{
+		{Float(), `1e400`, new(float64), 0, true},
+		{Float().RejectNonFinite(), `-1e400`, new(float64), 0, true},
+		{Float().AllowNonFinite(), `1e400`, new(float64), math.Inf(1), false},
+		{Float().AllowNonFinite(), `-1e400`, new(float64), math.Inf(-1), false},
+		{Float(), `1e300`, new(float64), 1e300, false},
+		{Float(), `1e300`, new(float32), 0, true},
+		{Float().AllowNonFinite(), `1e300`, new(float32), math.Inf(1), false},
+		{Float(), `24`, new(float64), 24, false},
+		{Float(MaxF(10)).AllowNonFinite(), `1e400`, new(float64), 0, true},
+	}
+
+	for i, c := range cases {
+		if err := c.s.Prepare(reflect.TypeOf(c.dest).Elem()); err != nil {
+			t.Fatal(err)
+		}
+
+		s := NewScanner(bytes.NewBufferString(c.json))
+		err := c.s.Parse(func() string { return "/" }, s, c.dest)
+		if c.wantErr {
+			if _, ok := err.(ValidationError); !ok {
+				t.Errorf("Case %d: Got %v for %v, want a ValidationError", i, err, c.json)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Case %d: Unexpected error for %v: %v", i, c.json, err)
+			continue
+		}
+
+		got := reflect.ValueOf(c.dest).Elem().Float()
+		if got != c.want {
+			t.Errorf("Case %d: Got %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func Test_MergeProps(t *testing.T) {
+	type personV1 struct {
+		Name string
+		Age  int64
+	}
+	type personV2 struct {
+		Name  string
+		Age   int64
+		Email string
+	}
+
+	v1Props := []StructPropInfo{Prop("Name", String(MinLen(1))), Prop("Age", Integer())}
+	v2Props := MergeProps(v1Props, Prop("Age", Integer(MinI(18))), Prop("Email", String()))
+	v1 := Parser(&personV1{}, Struct(v1Props...))
+	v2 := Parser(&personV2{}, Struct(v2Props...))
+
+	if len(v1Props) != 2 || len(v2Props) != 3 {
+		t.Fatalf("Got %d and %d props, want 2 and 3", len(v1Props), len(v2Props))
+	}
+
+	var got1 personV1
+	if err := v1.Parse(bytes.NewBufferString(`{"Name": "Bob", "Age": 12}`), &got1); err != nil {
+		t.Fatal(err)
+	} else if want := (personV1{"Bob", 12}); got1 != want {
+		t.Fatalf("Got %v, want %v", got1, want)
+	}
+
+	var got2 personV2
+	if err := v2.Parse(bytes.NewBufferString(`{"Name": "Bob", "Age": 21, "Email": "bob@example.com"}`), &got2); err != nil {
+		t.Fatal(err)
+	} else if want := (personV2{"Bob", 21, "bob@example.com"}); got2 != want {
+		t.Fatalf("Got %v, want %v", got2, want)
+	}
+
+	// the replaced prop applies to v2 only, the shared one to both
+	err := v2.Parse(bytes.NewBufferString(`{"Name": "", "Age": 12, "Email": "bob@example.com"}`), &got2)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 2 || verr[0].Path != "/Name" || verr[1].Path != "/Age" {
+		t.Fatalf("Got %v, want errors for /Name and /Age", err)
+	}
+	err = v1.Parse(bytes.NewBufferString(`{"Name": "", "Age": 12}`), &got1)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/Name" {
+		t.Fatalf("Got %v, want an error for /Name", err)
+	}
+}
+
+func Test_SliceOfScalars(t *testing.T) {
+	cases := []struct {
+		schema SchemaType
+		json   string
+		want   interface{}
+	}{
+		{Integer(), `[1, -2, 3]`, []int{1, -2, 3}},
+		{Integer(), `[1, -2, 127]`, []int8{1, -2, 127}},
+		{Integer(), `[1, -2, 3]`, []int16{1, -2, 3}},
+		{Integer(), `[1, -2, 3]`, []int32{1, -2, 3}},
+		{Integer(), `[1, -2, 3]`, []int64{1, -2, 3}},
+		{Integer(), `[1, 2, 3]`, []uint{1, 2, 3}},
+		{Integer(), `[1, 2, 255]`, []uint8{1, 2, 255}},
+		{Integer(), `[1, 2, 3]`, []uint16{1, 2, 3}},
+		{Integer(), `[1, 2, 3]`, []uint32{1, 2, 3}},
+		{Integer(), `[1, 2, 3]`, []uint64{1, 2, 3}},
+		{Float(), `[1.5, -2, 3]`, []float32{1.5, -2, 3}},
+		{Float(), `[1.5, -2, 3]`, []float64{1.5, -2, 3}},
+		{Boolean(), `[true, false, true]`, []bool{true, false, true}},
+		{String(), `["a", "", "c"]`, []string{"a", "", "c"}},
+	}
+
+	for i, c := range cases {
+		typ := reflect.TypeOf(c.want)
+
+		// an existing slice with room for all the items, so it's reused
+		dest := reflect.New(typ)
+		dest.Elem().Set(reflect.MakeSlice(typ, 1, 8))
+		backing := dest.Elem().Pointer()
+
+		if next, _ := scalarSlice(dest.Interface()); next == nil {
+			t.Errorf("Case %d: %v isn't handled by scalarSlice", i, typ)
+		}
+		if err := tryParse(Slice(c.schema), c.json, dest.Interface(), c.want); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		} else if dest.Elem().Pointer() != backing {
+			t.Errorf("Case %d: Got a new backing array, want the existing one reused", i)
+		}
+
+		// and a nil one, which must be allocated
+		dest = reflect.New(typ)
+		if err := tryParse(Slice(c.schema), c.json, dest.Interface(), c.want); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		}
+
+		// shrinking it to fit fewer items
+		if err := tryParse(Slice(c.schema), `[]`, dest.Interface(), reflect.MakeSlice(typ, 0, 0).Interface()); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		}
+	}
+}
+
+func Test_SliceArray(t *testing.T) {
+	type pixel struct {
+		RGB [3]int64
+	}
+	schema := Struct(Prop("RGB", Slice(Integer(RangeI(0, 255)))))
+
+	cases := []struct {
+		json  string
+		want  [3]int64
+		paths []string
+	}{
+		{`{"RGB": [255, 128, 0]}`, [3]int64{255, 128, 0}, nil},
+		{`{"RGB": [255, 128]}`, [3]int64{255, 128, 0}, []string{"/RGB"}},
+		{`{"RGB": []}`, [3]int64{}, []string{"/RGB"}},
+		{`{"RGB": [1, 2, 3, 4]}`, [3]int64{1, 2, 3}, []string{"/RGB"}},
+		{`{"RGB": [1, 256, 3, -1]}`, [3]int64{1, 0, 3}, []string{"/RGB1/", "/RGB3/", "/RGB"}},
+	}
+
+	for i, c := range cases {
+		// start full, to check nothing is left over
+		got := pixel{[3]int64{9, 9, 9}}
+		err := Parser(&got, schema).Parse(bytes.NewBufferString(c.json), &got)
+		var gotPaths []string
+		if verr, ok := err.(ValidationError); ok {
+			for _, e := range verr {
+				gotPaths = append(gotPaths, e.Path)
+			}
+		} else if err != nil {
+			t.Fatalf("Case %d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(gotPaths, c.paths) {
+			t.Errorf("Case %d: got error paths %v, want %v", i, gotPaths, c.paths)
+		}
+		if c.paths == nil && got.RGB != c.want {
+			t.Errorf("Case %d: Got %v, want %v", i, got.RGB, c.want)
+		}
+	}
+
+	// the wrong length is reported when only validating too
+	err := Parser(&pixel{}, schema).Validate(bytes.NewBufferString(`{"RGB": [1, 2]}`))
+	if verr, ok := err.(ValidationError); !ok || verr[0].Error != "Please provide exactly 3 items" {
+		t.Errorf("Got %v, want the length error", err)
+	}
+
+	// validators see the items stored
+	var pair [2]string
+	if err := tryParse(Slice(String(), MaxItems(1)), `["a", "b"]`, &pair, [2]string{"a", "b"}); err == nil {
+		t.Errorf("Got no error, wanted one from MaxItems")
+	}
+
+	// and Auto maps arrays to Slice
+	var auto [2]string
+	if err := tryParse(Auto(), `["a", "b"]`, &auto, [2]string{"a", "b"}); err != nil {
+		t.Errorf("Got error %v from Auto, want nil", err)
+	}
+
+	// but OneOrMany doesn't take arrays
+	if err := OneOrMany(String()).Prepare(reflect.TypeOf(pair)); err == nil {
+		t.Errorf("Got no error preparing OneOrMany for an array, wanted one")
+	}
+}
+
+func Test_SliceOfBytes(t *testing.T) {
+	// enough elements that the scanner's buffer is re-filled many times over,
+	// so any element still referencing it would be overwritten
+	want := make([][]byte, 200)
+	plain := make([]string, len(want))
+	encoded := make([]string, len(want))
+	for i := range want {
+		want[i] = []byte(fmt.Sprintf("blob %d/%d", i, len(want)))
+		plain[i] = fmt.Sprintf("%q", want[i])
+		encoded[i] = `"` + base64.StdEncoding.EncodeToString(want[i]) + `"`
+	}
+
+	cases := []struct {
+		s    SchemaType
+		json string
+	}{
+		{Slice(Bytes()), "[" + strings.Join(plain, ", ") + "]"},
+		{Slice(Base64Bytes()), "[" + strings.Join(encoded, ", ") + "]"},
+		{Slice(Base64Bytes()), "[" + strings.Replace(strings.Join(encoded, ", "), "/", `\/`, -1) + "]"},
+	}
+
+	for i, c := range cases {
+		var got [][]byte
+		if err := Parser(&got, c.s).Parse(bytes.NewBufferString(c.json), &got); err != nil {
+			t.Fatalf("Case %d: %v", i, err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Case %d: Got %q, want %q", i, got, want)
+		}
+	}
+
+	// bad elements are reported at their own path
+	var got [][]byte
+	err := Parser(&got, Slice(Base64Bytes(MaxLen(3)))).Parse(bytes.NewBufferString(`["AQID", "AQIDBA==", "not base64!"]`), &got)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 2 || verr[0].Path != "/1/" || verr[1].Path != "/2/" {
+		t.Fatalf("Got %v, want errors for /1/ and /2/", err)
+	}
+}
+
+func Test_Map(t *testing.T) {
+	type slug string
+
+	cases := []struct {
+		t    SchemaType
+		json string
+		dest interface{}
+		want interface{}
+	}{
+		{Map(Integer()), `{}`, &map[string]int64{}, map[string]int64{}},
+		{Map(Integer()), `{"a": 1, "b": 2, "a": 3}`, &map[string]int64{}, map[string]int64{"a": 3, "b": 2}},
+		{Map(String()), `{"a\u0062": "c"}`, new(map[string]string), map[string]string{"ab": "c"}},
+		{Map(Integer()), `{"b": 2}`, &map[slug]int64{"a": 1}, map[slug]int64{"a": 1, "b": 2}},
+		{Map(Slice(Integer())), `{"a": [1, 2], "b": [3]}`, new(map[string][]int64), map[string][]int64{"a": {1, 2}, "b": {3}}},
+		{Map(Struct(Prop("Captcha", String()), Prop("Fullname", String()))), `{"x": {"Captcha": "c", "Fullname": "f"}}`, new(map[string]simpleStruct), map[string]simpleStruct{"x": {"c", "f"}}},
+	}
+
+	for i, c := range cases {
+		if err := tryParse(c.t, c.json, c.dest, c.want); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		}
+	}
+}
+
+func Test_MapEntries(t *testing.T) {
+	schema := Map(Integer(MinI(0))).Entries(MinItems(1), MaxItems(2))
+
+	cases := []struct {
+		json  string
+		paths []string
+	}{
+		{`{"a": 1}`, nil},
+		{`{"a": 1, "b": 2}`, nil},
+		{`{}`, []string{"/"}},
+		{`{"a": 1, "b": 2, "c": 3}`, []string{"/"}},
+		{`{"a": -1, "b": 2, "c": 3}`, []string{"/a", "/"}},
+	}
+
+	for i, c := range cases {
+		got := map[string]int64{}
+		err := Parser(&got, schema).Parse(bytes.NewBufferString(c.json), &got)
+		var gotPaths []string
+		if verr, ok := err.(ValidationError); ok {
+			for _, e := range verr {
+				gotPaths = append(gotPaths, e.Path)
+			}
+		} else if err != nil {
+			t.Fatalf("Case %d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(gotPaths, c.paths) {
+			t.Errorf("Case %d: got error paths %v, want %v", i, gotPaths, c.paths)
+		}
+	}
+
+	// any count validator will do
+	even := CountValidatorFunc(func(n int) error {
+		if n%2 != 0 {
+			return fmt.Errorf("Must have an even number of entries")
+		}
+		return nil
+	})
+	got := map[string]int64{}
+	err := Parser(&got, Map(Integer()).Entries(even)).Parse(bytes.NewBufferString(`{"a": 1, "b": 2, "c": 3}`), &got)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Error != "Must have an even number of entries" {
+		t.Errorf("Got %v, want the count validator's error", err)
+	}
+}
+
+func Test_SliceContains(t *testing.T) {
+	type user struct {
+		Roles []string
+	}
+	admin := Contains(func(v reflect.Value) bool { return v.String() == "admin" }, "Must include the admin role")
+	parser := Parser(&user{}, Struct(Prop("Roles", Slice(String(), MinItems(1), admin))))
+
+	cases := []struct {
+		json   string
+		errors []string
+	}{
+		{`{"Roles": ["admin"]}`, nil},
+		{`{"Roles": ["viewer", "admin", "editor"]}`, nil},
+		{`{"Roles": ["viewer"]}`, []string{"Must include the admin role"}},
+		{`{"Roles": []}`, []string{"Please provide at least 1 items", "Must include the admin role"}},
+	}
+
+	for i, c := range cases {
+		var got user
+		err := parser.Parse(bytes.NewBufferString(c.json), &got)
+		var gotErrors []string
+		if verr, ok := err.(ValidationError); ok {
+			for _, e := range verr {
+				if e.Path != "/Roles" {
+					t.Errorf("Case %d: Got error at %v, want /Roles", i, e.Path)
+				}
+				gotErrors = append(gotErrors, e.Error)
+			}
+		} else if err != nil {
+			t.Fatalf("Case %d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(gotErrors, c.errors) {
+			t.Errorf("Case %d: Got errors %v, want %v", i, gotErrors, c.errors)
+		}
+	}
+
+	// with the default message
+	err := Contains(func(v reflect.Value) bool { return v.Int() > 10 }, "").ValidateSlice(reflect.ValueOf([]int{1, 2}))
+	if err == nil || err.Error() != ERROR_CONTAINS {
+		t.Errorf("Got %v, want %q", err, ERROR_CONTAINS)
+	}
+
+	// and when only validating
+	if err := parser.Validate(bytes.NewBufferString(`{"Roles": ["viewer"]}`)); err == nil {
+		t.Errorf("Got no error validating, wanted one")
+	}
+
+	// it needs the items, so it mustn't be usable where there are only counts
+	if _, ok := interface{}(admin).(CountValidator); ok {
+		t.Errorf("Contains is a CountValidator, so Map Entries would accept it")
+	}
+}
+
+func Test_MapKeyValidators(t *testing.T) {
+	schema := Map(Integer(MinI(0)), Pattern("^[a-z0-9-]+$", "slug"), MaxLen(8))
+	json := `{"good-1": 1, "Bad": 2, "good-2": -1, "bad slug": {"x": [3]}, "much-too-long": 4, "good-3": 5}`
+
+	got := map[string]int64{}
+	err := Parser(&got, schema).Parse(bytes.NewBufferString(json), &got)
+	verr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Got %v, want a ValidationError", err)
+	}
+
+	wantPaths := []string{"/Bad", "/good-2", "/bad slug", "/much-too-long"}
+	if len(verr) != len(wantPaths) {
+		t.Fatalf("Got %v, want errors for %v", verr, wantPaths)
+	}
+	for i, e := range verr {
+		if e.Path != wantPaths[i] {
+			t.Errorf("Error %d: Got path %v, want %v", i, e.Path, wantPaths[i])
+		}
+	}
+	if want := "slug"; verr[0].Error != want {
+		t.Errorf("Got %q, want %q", verr[0].Error, want)
+	}
+
+	// the valid props are still kept
+	if want := map[string]int64{"good-1": 1, "good-3": 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+
+	// and validating reports the same errors
+	err = Parser(&got, schema).ValidateBytes([]byte(json))
+	if !reflect.DeepEqual(err, verr) {
+		t.Errorf("Got %v from Validate, want %v", err, verr)
+	}
+}
+
+func Test_Pairs(t *testing.T) {
+	type pair struct {
+		Key   string
+		Value int64
+	}
+	type header struct {
+		Name   string
+		Values []string
+	}
+
+	cases := []struct {
+		t    SchemaType
+		json string
+		dest interface{}
+		want interface{}
+	}{
+		{Pairs(Integer()), `{}`, &[]pair{}, []pair{}},
+		{Pairs(Integer()), `{"b": 2, "a": 1, "b": 3, "c": 4, "a": 5}`, new([]pair), []pair{{"b", 2}, {"a", 1}, {"b", 3}, {"c", 4}, {"a", 5}}},
+		{Pairs(Integer()), `{"z": 1}`, &[]pair{{"x", 1}, {"y", 2}}, []pair{{"z", 1}}},
+		{Pairs(Slice(String())), `{"Accept": ["a", "b"], "Accept": ["c"]}`, new([]header), []header{{"Accept", []string{"a", "b"}}, {"Accept", []string{"c"}}}},
+	}
+
+	for i, c := range cases {
+		if err := tryParse(c.t, c.json, c.dest, c.want); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		}
+	}
+
+	// invalid values are reported at their key, and left out
+	var got []pair
+	err := Parser(&got, Pairs(Integer(MinI(0)))).Parse(bytes.NewBufferString(`{"a": 1, "b": -1, "a": -2, "c": 3}`), &got)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 2 || verr[0].Path != "/b" || verr[1].Path != "/a" {
+		t.Fatalf("Got %v, want errors for /b and /a", err)
+	} else if want := []pair{{"a", 1}, {"c", 3}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Got %v, want %v", got, want)
+	}
+
+	// only slices of key/value structs will do
+	bad := []interface{}{
+		[]string{},
+		[]struct{ Key, Value, Extra string }{},
+		[]struct {
+			Key   int64
+			Value string
+		}{},
+		[]struct {
+			Key   string
+			value string
+		}{},
+	}
+	for i, b := range bad {
+		if err := Pairs(String()).Prepare(reflect.TypeOf(b)); err == nil {
+			t.Errorf("Bad case %d: Got no error, want one", i)
+		}
+	}
+}
+
+func Test_StructDottedKeys(t *testing.T) {
+	type address struct {
+		Street string
+		City   string
+	}
+	type user struct {
+		Name    string
+		Age     int64
+		Address *address
+	}
+	type form struct {
+		User  user
+		Tags  *[]string
+		Token string
+	}
+
+	addrSchema := Struct(Prop("Street", String()), Prop("City", String()))
+	userSchema := Struct(Prop("Name", String()), Prop("Age", Integer(MinI(0))), Prop("Address", addrSchema))
+	schema := Struct(Prop("User", userSchema), Prop("Tags", Slice(String())), Prop("Token", String())).DottedKeys()
+
+	tags := []string{"a", "b"}
+	cases := []struct {
+		json string
+		want form
+	}{
+		// fully flattened
+		{`{"User.Name": "Bob", "User.Age": 24, "Token": "t"}`, form{User: user{Name: "Bob", Age: 24}, Token: "t"}},
+		// nested a few levels, mixed with plain keys and values of any type
+		{
+			`{"Tags": ["a", "b"], "User.Address.City": "Sydney", "User.Name": "Bob", "User.Address.Street": "1 George St", "User.Age": 24, "Token": "t"}`,
+			form{User: user{Name: "Bob", Age: 24, Address: &address{"1 George St", "Sydney"}}, Tags: &tags, Token: "t"},
+		},
+		{
+			`{"Tags": ["a", "b"], "User": {"Name": "Al", "Age": 1}, "User.Name": "Bob", "User.Age": 24, "User.Address": {"City": "Sydney", "Street": "1 George St"}, "Token": "t"}`,
+			form{User: user{Name: "Bob", Age: 24, Address: &address{"1 George St", "Sydney"}}, Tags: &tags, Token: "t"},
+		},
+		// the last value for a prefix wins
+		{`{"User.Name": "Al", "User.Age": 1, "User": {"Name": "Bob", "Age": 24}, "Token": "t"}`, form{User: user{Name: "Bob", Age: 24}, Token: "t"}},
+		{`{"User": {"Name": "Al", "Age": 1}, "User.Name": "Bob", "User.Age": 24, "Token": "t"}`, form{User: user{Name: "Bob", Age: 24}, Token: "t"}},
+		// escaped keys are unescaped before splitting
+		{`{"User\u002eName": "Bob", "User.A\u0067e": 24, "Token": "t"}`, form{User: user{Name: "Bob", Age: 24}, Token: "t"}},
+	}
+
+	for i, c := range cases {
+		if err := tryParse(schema, c.json, &form{}, c.want); err != nil {
+			t.Errorf("Case %d: %v", i, err)
+		}
+	}
+
+	// errors are reported at the nested paths, as for the unflattened object
+	var got form
+	err := Parser(&got, schema).Parse(bytes.NewBufferString(`{"User.Name": "Bob", "User.Age": -1, "Token": 5}`), &got)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 2 || verr[0].Path != "/UserAge" || verr[1].Path != "/Token" {
+		t.Errorf("Got %v, want errors for /UserAge and /Token", err)
+	}
+
+	// without DottedKeys, they're just unknown props
+	plain := Struct(Prop("User", userSchema), Prop("Tags", Slice(String())), Prop("Token", String()))
+	err = Parser(&got, plain).Parse(bytes.NewBufferString(`{"User.Name": "Bob", "User.Age": 24, "Token": "t"}`), &got)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/User" {
+		t.Errorf("Got %v, want an error for /User", err)
+	}
+
+	// malformed input is still malformed
+	malformed := []string{
+		`{"User.Name": "Bob", "User.Age" 24}`,
+		`{"User.Name": ["Bob"}`,
+		`{"User.Name": {"a": 1,}}`,
+	}
+	for i, json := range malformed {
+		if err := Parser(&got, schema).Parse(bytes.NewBufferString(json), &got); err == nil {
+			t.Errorf("Malformed case %d: Got no error, want one", i)
+		} else if _, ok := err.(*ParseError); !ok {
+			t.Errorf("Malformed case %d: Got %T %v, want a ParseError", i, err, err)
+		}
+	}
+}
+
+func Test_Auto(t *testing.T) {
+	type inner struct {
+		Label string
+		Score float32
+	}
+	type mixed struct {
+		Name     string
+		Age      int64
+		Small    int8
+		Count    uint
+		Color    enumColor
+		Ratio    float64
+		Active   bool
+		Born     time.Time
+		Avatar   []byte
+		Tags     []string
+		Counts   map[string]int64
+		Inner    inner
+		Children []inner
+		Nickname *string `json:"nick"`
+	}
+
+	json := `{
+		"Name": "Bob", "Age": 42, "Small": -3, "Count": 7, "Color": 2, "Ratio": 0.5,
+		"Active": true, "Born": "2001-02-03 04:05:06", "Avatar": "AQID",
+		"Tags": ["a", "b"], "Counts": {"x": 1},
+		"Inner": {"Label": "in", "Score": 1.5},
+		"Children": [{"Label": "c1", "Score": 2}, {"Label": "c2", "Score": 3}],
+		"nick": "B"
+	}`
+	nick := "B"
+	want := mixed{
+		Name: "Bob", Age: 42, Small: -3, Count: 7, Color: enumBlue, Ratio: 0.5,
+		Active: true, Born: mkDateTime(2001, 2, 3, 4, 6, 5), Avatar: []byte{1, 2, 3},
+		Tags: []string{"a", "b"}, Counts: map[string]int64{"x": 1},
+		Inner:    inner{"in", 1.5},
+		Children: []inner{{"c1", 2}, {"c2", 3}},
+		Nickname: &nick,
+	}
+
+	var got mixed
+	if err := Parser(&got, Auto()).Parse(bytes.NewBufferString(json), &got); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Got %+v, want %+v", got, want)
+	}
+
+	// non-pointer fields are required, and types are checked
+	got = mixed{}
+	err := Parser(&got, Auto()).Parse(bytes.NewBufferString(`{"Name": 5, "Inner": {"Label": "in"}}`), &got)
+	if verr, ok := err.(ValidationError); !ok {
+		t.Fatalf("Got %v, want a ValidationError", err)
+	} else if len(verr) != 13 || verr[0].Path != "/Name" {
+		t.Fatalf("Got %v, want 13 errors starting with /Name", verr)
+	}
+
+	// recursive types
+	type node struct {
+		Value    int64
+		Children []node
+	}
+	var tree node
+	wantTree := node{1, []node{{2, []node{}}, {3, []node{{4, []node{}}}}}}
+	err = Parser(&tree, Auto()).Parse(bytes.NewBufferString(`{"Value": 1, "Children": [{"Value": 2, "Children": []}, {"Value": 3, "Children": [{"Value": 4, "Children": []}]}]}`), &tree)
+	if err != nil {
+		t.Fatal(err)
+	} else if fmt.Sprint(tree) != fmt.Sprint(wantTree) {
+		t.Fatalf("Got %v, want %v", tree, wantTree)
+	}
+
+	// unsupported types
+	for _, v := range []interface{}{new(chan int), new(map[int]string), new(struct{ F func() })} {
+		if _, err := ParserError(v, Auto()); err == nil {
+			t.Errorf("%T: Got no error, want one", v)
+		}
+	}
+}
+
+type oneOfEvent interface{}
+
+type oneOfClick struct {
+	X int64
+	Y int64
+}
+
+type oneOfKey struct {
+	Key string
+}
+
+func Test_OneOf(t *testing.T) {
+	events := func() SchemaType {
+		return Slice(OneOf("type", map[string]OneOfCase{
+			"click": Case(&oneOfClick{}, Struct(Prop("X", Integer()), Prop("Y", Integer()))),
+			"key":   Case(oneOfKey{}, Struct(Prop("Key", String()))),
+		}))
+	}
+
+	// each element is dispatched on its own, wherever the discriminator is
+	json := `[
+		{"type": "click", "X": 1, "Y": 2},
+		{"Key": "a", "type": "key"},
+		{"X": 3, "type": "click", "Y": 4}
+	]`
+	want := []oneOfEvent{&oneOfClick{1, 2}, oneOfKey{"a"}, &oneOfClick{3, 4}}
+	if err := tryParse(events(), json, new([]oneOfEvent), want); err != nil {
+		t.Fatal(err)
+	}
+	if err := tryParse(events(), json, new([]interface{}), []interface{}{want[0], want[1], want[2]}); err != nil {
+		t.Fatal(err)
+	}
+
+	// errors keep the element's path
+	var got []oneOfEvent
+	json = `[
+		{"type": "click", "X": 1, "Y": 2},
+		{"type": "key", "Key": 3},
+		{"type": "scroll"},
+		{"X": 1, "Y": 2},
+		"click",
+		{"type": "click", "X": 1},
+		{"type": 7}
+	]`
+	err := Parser(&got, events()).Parse(bytes.NewBufferString(json), &got)
+	wantPaths := []string{"/1/Key", "/2/type", "/3/", "/4/", "/5/Y", "/6/type"}
+	verr, ok := err.(ValidationError)
+	if !ok || len(verr) != len(wantPaths) {
+		t.Fatalf("Got %v, want errors for %v", err, wantPaths)
+	}
+	for i, p := range wantPaths {
+		if verr[i].Path != p {
+			t.Errorf("Error %d: Got path %q, want %q", i, verr[i].Path, p)
+		}
+	}
+	if wantMsg := `"scroll" is not allowed; expected one of: "click", "key"`; verr[1].Error != wantMsg {
+		t.Errorf("Got %q, want %q", verr[1].Error, wantMsg)
+	}
+
+	// it can still be validated without a destination
+	if err := Parser(&got, events()).Validate(bytes.NewBufferString(`[{"type": "key", "Key": "a"}]`)); err != nil {
+		t.Fatalf("Got %v, want no error", err)
+	}
+
+	// malformed JSON is still an error
+	err = Parser(&got, events()).Parse(bytes.NewBufferString(`[{"type": "key", "Key": }]`), &got)
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Got %v, want a ParseError", err)
+	}
+
+	// the destination must be an interface all the cases can be stored in
+	clicks := OneOf("type", map[string]OneOfCase{
+		"click": Case(oneOfClick{}, Struct(Prop("X", Integer()), Prop("Y", Integer()))),
+	})
+	bad := []reflect.Type{
+		reflect.TypeOf(oneOfClick{}),
+		reflect.TypeOf(new(fmt.Stringer)).Elem(),
+	}
+	for i, b := range bad {
+		if _, ok := clicks.Prepare(b).(*SchemaConfigError); !ok {
+			t.Errorf("Bad case %d: Got no SchemaConfigError for %v", i, b)
+		}
+	}
+}
+
+func Test_Any(t *testing.T) {
+	cases := []struct {
+		t    SchemaType
+		json string
+		want interface{}
+	}{
+		{Any(), `null`, nil},
+		{Any(), `true`, true},
+		{Any(), `"a\nb"`, "a\nb"},
+		{Any(), `1.5`, 1.5},
+		{Any(), `[]`, []interface{}{}},
+		{Any(), `{}`, map[string]interface{}{}},
+		{Any(), `{"a": [1, "b", {"c": null}], "d": false}`, map[string]interface{}{
+			"a": []interface{}{1.0, "b", map[string]interface{}{"c": nil}},
+			"d": false,
+		}},
+		{Any().UseNumber(), `[1, 1.50, -2e3]`, []interface{}{json.Number("1"), json.Number("1.50"), json.Number("-2e3")}},
+	}
+
+	for i, c := range cases {
+		var got interface{}
+		if err := Parser(&got, c.t).Parse(strings.NewReader(c.json), &got); err != nil {
+			t.Errorf("Case %d: Got error %v, want nil", i, err)
+		} else if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Case %d: Got %#v, want %#v", i, got, c.want)
+		}
+	}
+
+	// a catch-all for metadata, where big ids must survive
+	type doc struct {
+		Name string
+		Meta map[string]interface{}
+	}
+	schema := Struct(Prop("Name", String()), Prop("Meta", Map(Any().UseNumber())))
+	var got doc
+	err := Parser(&got, schema).Parse(strings.NewReader(`{"Name": "a", "Meta": {"ids": [{"id": 12345678901234567891}]}}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := got.Meta["ids"].([]interface{})
+	if id := ids[0].(map[string]interface{})["id"]; id != json.Number("12345678901234567891") {
+		t.Fatalf("Got %#v, want the exact json.Number", id)
+	}
+
+	// without UseNumber it's a float, and out of range ones are invalid
+	var v interface{}
+	err = Parser(&v, Any()).Parse(strings.NewReader(`{"a": [1, 1e400]}`), &v)
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 1 || verr[0].Path != "/a1/" {
+		t.Fatalf("Got %v, want an error for /a1/", err)
+	}
+
+	// only an interface{} will hold anything
+	if _, ok := Any().Prepare(reflect.TypeOf(new(fmt.Stringer)).Elem()).(*SchemaConfigError); !ok {
+		t.Fatalf("Got no SchemaConfigError for fmt.Stringer")
+	}
+}
+
+func Test_StructCaptureRaw(t *testing.T) {
+	type entry struct {
+		Id   int64
+		Tags []string
+		Raw  json.RawMessage
+	}
+	schema := func() SchemaType {
+		return Struct(Prop("Id", Integer()), Prop("Tags", Slice(String()))).CaptureRaw("Raw")
+	}
+
+	// long enough that the scanner's buffer is refilled part way through
+	obj := `{ "Id": 42,
+		"Tags": [` + strings.TrimSuffix(strings.Repeat(`"tag", `, 200), ", ") + `],
+		"Unknown": {"a": [1, 2, 3]}
+	}`
+	want := entry{Id: 42, Tags: make([]string, 200), Raw: json.RawMessage(obj)}
+	for i := range want.Tags {
+		want.Tags[i] = "tag"
+	}
+
+	var got entry
+	s := NewScanner(&oneByteReader{bytes.NewReader([]byte("  \n" + obj + "  "))})
+	if err := Parser(&got, schema()).ParseScanner(s, &got); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Got %s, want %s", got.Raw, want.Raw)
+	}
+
+	got = entry{}
+	if err := Parser(&got, schema()).ParseBytes([]byte(obj), &got); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Got %s, want %s", got.Raw, want.Raw)
+	}
+
+	// each element gets its own, even when nested in another capture
+	type batch struct {
+		Entries []entry
+		Raw     []byte
+	}
+	batchSchema := Struct(Prop("Entries", Slice(schema()))).CaptureRaw("Raw")
+	json := `{"Entries": [{"Id": 1, "Tags": []}, {"Id": 2,  "Tags": ["x"]}]}`
+	var gotBatch batch
+	err := Parser(&gotBatch, batchSchema).Parse(strings.NewReader(json), &gotBatch)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(gotBatch.Raw) != json {
+		t.Fatalf("Got %s, want %s", gotBatch.Raw, json)
+	} else if e := gotBatch.Entries; len(e) != 2 || string(e[0].Raw) != `{"Id": 1, "Tags": []}` || string(e[1].Raw) != `{"Id": 2,  "Tags": ["x"]}` {
+		t.Fatalf("Got %v, want each entry's own JSON", e)
+	}
+
+	// it's still set if the object is invalid
+	got = entry{}
+	err = Parser(&got, schema()).Parse(strings.NewReader(`{"Id": "x", "Tags": []}`), &got)
+	if _, ok := err.(ValidationError); !ok || string(got.Raw) != `{"Id": "x", "Tags": []}` {
+		t.Fatalf("Got %v and %s, want a ValidationError and the raw JSON", err, got.Raw)
+	}
+
+	// the field must be an exported []byte
+	bad := []interface{}{
+		struct{ Id int64 }{},
+		struct {
+			Id  int64
+			Raw string
+		}{},
+		struct {
+			Id  int64
+			raw []byte
+		}{},
+	}
+	for i, b := range bad {
+		err := Struct(Prop("Id", Integer())).CaptureRaw("Raw").Prepare(reflect.TypeOf(b))
+		if _, ok := err.(*SchemaConfigError); !ok {
+			t.Errorf("Bad case %d: Got %v, want a SchemaConfigError", i, err)
+		}
+	}
+}
+
+type unmarshalPhoneError struct {
+	digits int
+}
+
+func (e *unmarshalPhoneError) Error() string {
+	return fmt.Sprintf("Phone numbers need 10 digits, not %d", e.digits)
+}
+
+type unmarshalPhone string
+
+func (p *unmarshalPhone) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	} else if len(s) != 10 {
+		return &unmarshalPhoneError{len(s)}
+	}
+	*p = unmarshalPhone(s)
+	return nil
+}
+
+// can't be compared with ==, as it holds a slice
+type incomparableError struct {
+	msgs []string
+}
+
+func (e incomparableError) Error() string {
+	return strings.Join(e.msgs, ", ")
+}
+
+func Test_UnmarshalerError(t *testing.T) {
+	type contact struct {
+		Name  string
+		Phone unmarshalPhone
+	}
+	schema := Struct(Prop("Name", String(MinLen(2))), Prop("Phone", Unmarshaler()))
+
+	var got contact
+	err := Parser(&got, schema).Parse(strings.NewReader(`{"Name": "B", "Phone": "12345"}`), &got)
+	verr, ok := err.(ValidationError)
+	if !ok || len(verr) != 2 || verr[1].Path != "/Phone" || verr[1].Error != "Phone numbers need 10 digits, not 5" {
+		t.Fatalf("Got %v, want errors for /Name and /Phone", err)
+	}
+
+	// the original error is still there
+	var perr *unmarshalPhoneError
+	if !errors.As(err, &perr) || perr.digits != 5 {
+		t.Fatalf("Got %v from errors.As, want the UnmarshalJSON error", perr)
+	} else if verr[1].Err() != error(perr) || verr[0].Err() != nil {
+		t.Fatalf("Got %v and %v, want only the Phone error kept", verr[0].Err(), verr[1].Err())
+	}
+
+	// which doesn't stop them being compared, even for incomparable errors
+	kept := ValidationError{}.AddErr("/", incomparableError{[]string{"a"}})
+	if kept[0] == (InvalidData{Path: "/", Error: "a"}) || kept[0] != kept[0] {
+		t.Fatalf("Got %v, want it only equal to itself", kept[0])
+	}
+
+	// and the messages are all that's marshalled
+	b, _ := json.Marshal(verr)
+	if want := `[{"Path":"/Name","Error":"Must be at least 2 bytes long"},{"Path":"/Phone","Error":"Phone numbers need 10 digits, not 5"}]`; string(b) != want {
+		t.Fatalf("Got %s, want %s", b, want)
+	}
+
+	err = Parser(&got, schema).Parse(strings.NewReader(`{"Name": "Bo", "Phone": "0123456789"}`), &got)
+	if err != nil || errors.As(err, &perr) {
+		t.Fatalf("Got %v, want nil", err)
+	}
+}
@@ -20,6 +20,10 @@ func Unmarshaler() *UnmarshalParser {
 	return &UnmarshalParser{}
 }
 
+func (p *UnmarshalParser) ExpectedType() JSONType {
+	return JSONAny
+}
+
 func (p *UnmarshalParser) Prepare(t reflect.Type) error {
 	if !t.Implements(UnmarshalerType) && !reflect.PtrTo(t).Implements(UnmarshalerType) {
 		return fmt.Errorf("Must implement the encoding/json Unmarshaler interface. %v does not.", t)
@@ -38,8 +42,7 @@ func (p *UnmarshalParser) Parse(path Pather, s *Scanner, v interface{}) error {
 		return NewParseError(ERROR_BAD_UNMARSHAL_DEST, reflect.TypeOf(v), path())
 	} else if err := dest.UnmarshalJSON(buf); err != nil {
 		var errs ValidationError
-		errs = errs.Add(path(), err.Error())
-		return errs
+		return errs.AddErr(path(), err)
 	}
 
 	return nil
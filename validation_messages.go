@@ -1,5 +1,11 @@
 package jsonv
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
 const (
 	// messages for bad destination types
 	ERROR_BAD_INT_DEST       = "Cannot assign integer to variable of type %v, path %v"
@@ -7,32 +13,103 @@ const (
 	ERROR_BAD_STRING_DEST    = "Cannot assign string to variable of type %v, path %v"
 	ERROR_BAD_DATE_DEST      = "Cannot assign date to variable of type %v, path %v"
 	ERROR_BAD_DATE_TIME_DEST = "Cannot assign datetime to variable of type %v, path %v"
+	ERROR_BAD_DURATION_DEST  = "Cannot assign duration to variable of type %v, path %v"
+	ERROR_BAD_NULLABLE_DEST  = "Cannot assign null to variable of type %v, path %v"
 	ERROR_BAD_BYTE_DEST      = "Cannot assign []byte to variable of type %v, path %v"
 	ERROR_BAD_BOOL_DEST      = "Cannot assign boolean to variable of type %v, path %v"
 	ERROR_BAD_UNMARSHAL_DEST = "Cannot unmashal into variable of type %v, path %v"
 	ERROR_BAD_OBJ_DEST       = "Must be a non-nil ptr to a struct, not %v"
 	ERROR_BAD_SLICE_DEST     = "Must be a non-nil ptr to a slice, not %v"
+	ERROR_BAD_MAP_DEST       = "Must be a non-nil ptr to a map with string keys, not %v"
+	ERROR_BAD_PAIRS_DEST     = "Must be a non-nil ptr to a slice of structs with a string key field and a value field, not %v"
+	ERROR_BAD_ONE_OF_DEST    = "Must be a non-nil ptr to an interface, not %v"
+	ERROR_BAD_ANY_DEST       = "Must be a non-nil ptr to an interface{}, not %v"
+
+	// returned by Prepare for a destination type a schema can't parse into
+	ERROR_PREPARE_DEST = "want %v destination, got %v"
+	ERROR_PREPARE_PROP = "prop %q on %v: %v"
+	ERROR_PREPARE_CASE = "case %q: %v is not assignable to %v"
+	ERROR_PREPARE_RAW  = "no exported []byte field %q for the raw JSON on %v"
 
 	ERROR_INVALID_STRING = "Expected a string, go %v"
 
+	ERROR_ONE_OF_KEY = "Expected an object with a string %q property"
+
+	ERROR_INVALID_BASE64 = "Must be base64 encoded"
+
 	ERROR_INVALID_DATE = "Expected a string in the format yyyy-mm-dd."
 
 	ERROR_INVALID_DATE_TIME = "Expected a string in the format yyyy-mm-ddTHH:MM:SS.000Z."
 
+	ERROR_INVALID_DURATION     = "Expected a duration string, got %v"
+	ERROR_INVALID_GO_DURATION  = "Expected a duration, e.g. 1h30m, got %q"
+	ERROR_INVALID_ISO_DURATION = "Expected an ISO 8601 duration, e.g. PT1H30M, got %q"
+	ERROR_ISO_DURATION_YEARS   = "Years and months aren't allowed in a duration, as their length varies"
+
+	ERROR_INVALID_FLEXIBLE_TIME = "Expected a date-time string or Unix timestamp, got %v"
+	ERROR_INVALID_UNIX_TIME     = "Expected a whole number Unix timestamp, got %v"
+
 	ERROR_INVALID_INT = "Expected an integer, got %v"
 	ERROR_PARSE_INT   = "Error parsing integer, %v"
 
+	ERROR_INVALID_FLOAT    = "Expected a number, got %v"
+	ERROR_FLOAT_NON_FINITE = "Must be a finite number"
+
+	ERROR_INT_NOT_WHOLE = "Must be a whole number"
+	ERROR_INT_NEGATIVE  = "Must not be negative"
+	ERROR_INT_PRECISION = "Must be between -%[1]v and %[1]v to be represented exactly"
+
 	ERROR_INVALID_BOOL = "Expected a boolean, got %v"
 	ERROR_PARSE_BOOL   = "Error parsing bool, %v"
 
+	ERROR_INVALID_NULL = "Expected null, got %v"
+
 	ERROR_PROP_REQUIRED = "Required"
+	ERROR_PROP_UNKNOWN  = "Unknown property"
+	ERROR_DUPLICATE_KEY = "Must only be given once"
+
+	ERROR_AT_LEAST_ONE_OF = "At least one of %v is required"
+	ERROR_EXACTLY_ONE_OF  = "Only one of %v can be provided"
 
-	ERROR_MIN_LEN_STR   = "Must be at least %d characters long"
-	ERROR_MAX_LEN_STR   = "Must be no more than %d characters long"
+	ERROR_ENUM = "%v is not allowed; expected one of: %v"
+
+	ERROR_TRAILING_COMMA_OBJ = "Trailing ',' before '}' is not allowed"
+	ERROR_TRAILING_COMMA_ARR = "Trailing ',' before ']' is not allowed"
+
+	ERROR_UNEXPECTED_EOF = "Unexpected end of input during parsing"
+
+	ERROR_KEY_TOO_LONG      = "Object key is longer than %d bytes, at %v"
+	ERROR_NUMBER_TOO_LONG   = "Number has more than %d digits, at %v"
+	ERROR_TOO_MANY_KEYS     = "Object has more than %d keys, at byte %d"
+	ERROR_TOO_DEEP          = "Nested more than %d deep, at byte %d"
+	ERROR_TOO_MANY_ELEMENTS = "Array or object has more than %d elements, at byte %d"
+	ERROR_INVALID_TOKEN     = "Expected valid JSON at %v, got %s"
+
+	ERROR_NON_STANDARD_NUMBER = "%s is not valid JSON, at %v"
+
+	ERROR_FRAME_TRAILING_DATA = "Expected exactly one JSON value in frame, found more at byte %d"
+
+	ERROR_MIN_LEN_STR   = "Must be at least %d bytes long"
+	ERROR_MAX_LEN_STR   = "Must be no more than %d bytes long"
+	ERROR_MIN_RUNES     = "Must be at least %d characters long"
+	ERROR_MAX_RUNES     = "Must be no more than %d characters long"
 	ERROR_PATTERN_MATCH = "Must match regex pattern %v"
+	ERROR_NOT_ASCII     = "Must only contain ASCII characters, found another at byte %d"
+	ERROR_INVALID_JSON  = "Must be valid JSON: %v"
+
+	// messages for the common formats
+	ERROR_EMAIL    = "Must be an email address"
+	ERROR_URL      = "Must be an absolute URL"
+	ERROR_UUID     = "Must be a UUID"
+	ERROR_IPV4     = "Must be an IPv4 address"
+	ERROR_IPV6     = "Must be an IPv6 address"
+	ERROR_HOSTNAME = "Must be a hostname"
 
 	ERROR_MIN_LEN_ARR = "Please provide at least %d items"
 	ERROR_MAX_LEN_ARR = "Please provide no more than %d items"
+	ERROR_ARRAY_LEN   = "Please provide exactly %d items"
+	ERROR_POSITIONAL  = "Expected at most %d items, got %d"
+	ERROR_CONTAINS    = "Please provide at least one matching item"
 
 	// general number validation errors
 	ERROR_MAX_EX = "Must be less than %v"
@@ -40,7 +117,32 @@ const (
 	ERROR_MIN_EX = "Must be greater than %v"
 	ERROR_MIN    = "Must be greater than or equal to %v"
 	ERROR_MULOF  = "Must be a multiple of %v"
+	ERROR_RANGE  = "Must be between %v and %v"
+
+	// messages for the common ranges, given the same args as ERROR_RANGE
+	ERROR_PORT      = "Must be a port number, between %v and %v"
+	ERROR_PERCENT   = "Must be a percentage, between %v and %v"
+	ERROR_LATITUDE  = "Must be a latitude, between %v and %v degrees"
+	ERROR_LONGITUDE = "Must be a longitude, between %v and %v degrees"
 
 	ERROR_NIL_DEFAULT        = `Default for "%v" cannot be nil. Use a ptr field with no default instead.`
 	ERROR_WRONG_TYPE_DEFAULT = "Default value must be the same type as field. Got %v, want %v"
 )
+
+/*
+The placeholder in a validator's custom message, see WithMessage, that's
+replaced by its limit, e.g. "Must be under {limit} characters".
+*/
+const limitPlaceholder = "{limit}"
+
+/*
+Builds the error for a validator with a limit, e.g. MinLen. A custom message
+given with WithMessage is used as is, other than replacing limitPlaceholder, so
+it can contain '%'. Otherwise the fmt template def is given the limit.
+*/
+func limitError(msg, def string, limit interface{}) error {
+	if msg == "" {
+		return fmt.Errorf(def, limit)
+	}
+	return errors.New(strings.Replace(msg, limitPlaceholder, fmt.Sprint(limit), -1))
+}
@@ -15,23 +15,51 @@ func (f SliceValidatorFunc) ValidateSlice(v reflect.Value) error {
 	return f(v)
 }
 
+/*
+Used to identify validators that only need the number of items, e.g. MinItems,
+so they can check collections whose items aren't kept, e.g. a Map's entries.
+*/
+type CountValidator interface {
+	ValidateCount(n int) error
+}
+
+type CountValidatorFunc func(n int) error
+
+func (f CountValidatorFunc) ValidateCount(n int) error {
+	return f(n)
+}
+
 /*
 The Min Length validator.
 */
 type MinItemsV struct {
-	l int
+	l   int
+	msg string
 }
 
 func MinItems(l int) *MinItemsV {
 	if l < 0 {
 		panic(fmt.Errorf("Minimum allowed length must be >= 0"))
 	}
-	return &MinItemsV{l}
+	return &MinItemsV{l: l}
+}
+
+/*
+Replaces the default error message, e.g. "Pick at least {limit} tags". Any
+"{limit}" is replaced by the item limit.
+*/
+func (m *MinItemsV) WithMessage(msg string) *MinItemsV {
+	m.msg = msg
+	return m
 }
 
 func (m *MinItemsV) ValidateSlice(v reflect.Value) error {
-	if v.Len() < m.l {
-		return fmt.Errorf(ERROR_MIN_LEN_ARR, m.l)
+	return m.ValidateCount(v.Len())
+}
+
+func (m *MinItemsV) ValidateCount(n int) error {
+	if n < m.l {
+		return limitError(m.msg, ERROR_MIN_LEN_ARR, m.l)
 	}
 	return nil
 }
@@ -40,19 +68,71 @@ func (m *MinItemsV) ValidateSlice(v reflect.Value) error {
 The Max Length validator.
 */
 type MaxItemsV struct {
-	l int
+	l   int
+	msg string
 }
 
 func MaxItems(l int) *MaxItemsV {
 	if l < 0 {
 		panic(fmt.Errorf("Maximum allowed length must be >= 0"))
 	}
-	return &MaxItemsV{l}
+	return &MaxItemsV{l: l}
+}
+
+/*
+Replaces the default error message, e.g. "Pick no more than {limit} tags". Any
+"{limit}" is replaced by the item limit.
+*/
+func (m *MaxItemsV) WithMessage(msg string) *MaxItemsV {
+	m.msg = msg
+	return m
 }
 
 func (m *MaxItemsV) ValidateSlice(v reflect.Value) error {
-	if v.Len() > m.l {
-		return fmt.Errorf(ERROR_MAX_LEN_ARR, m.l)
+	return m.ValidateCount(v.Len())
+}
+
+func (m *MaxItemsV) ValidateCount(n int) error {
+	if n > m.l {
+		return limitError(m.msg, ERROR_MAX_LEN_ARR, m.l)
 	}
 	return nil
 }
+
+/*
+Requires at least one item in the slice to satisfy pred, e.g. that a list of
+roles includes "admin":
+
+	Slice(String(), Contains(func(v reflect.Value) bool {
+		return v.String() == "admin"
+	}, "Must include the admin role"))
+
+pred is given each item in turn, until it returns true. The default message is
+used if msg is "".
+
+As it needs the items themselves, it isn't a CountValidator, so it can't be
+given to a Map's Entries.
+*/
+type ContainsV struct {
+	pred func(reflect.Value) bool
+	msg  string
+}
+
+func Contains(pred func(reflect.Value) bool, msg string) *ContainsV {
+	if pred == nil {
+		panic(fmt.Errorf("Contains needs a predicate"))
+	}
+	return &ContainsV{pred: pred, msg: msg}
+}
+
+func (c *ContainsV) ValidateSlice(v reflect.Value) error {
+	for i := 0; i < v.Len(); i++ {
+		if c.pred(v.Index(i)) {
+			return nil
+		}
+	}
+	if c.msg == "" {
+		return fmt.Errorf("%v", ERROR_CONTAINS)
+	}
+	return fmt.Errorf("%v", c.msg)
+}
@@ -0,0 +1,67 @@
+package jsonv
+
+import (
+	"time"
+)
+
+/*
+Validator type for Durations
+*/
+type DurationValidator interface {
+	ValidateDuration(time.Duration) error
+}
+
+type DurationValidatorFunc func(time.Duration) error
+
+func (f DurationValidatorFunc) ValidateDuration(d time.Duration) error {
+	return f(d)
+}
+
+/*
+Validates durations against a limit, m, e.g. MinDuration. The default message
+can be replaced with WithMessage.
+*/
+type DurationLimitV struct {
+	m   time.Duration
+	ok  func(v, m time.Duration) bool
+	def string
+	msg string
+}
+
+/*
+Replaces the default error message, e.g. "Timeout must be at least {limit}".
+Any "{limit}" is replaced by the limit.
+*/
+func (l *DurationLimitV) WithMessage(msg string) *DurationLimitV {
+	l.msg = msg
+	return l
+}
+
+func (l *DurationLimitV) ValidateDuration(d time.Duration) error {
+	if l.ok(d, l.m) {
+		return nil
+	}
+	return limitError(l.msg, l.def, l.m)
+}
+
+/*
+Minimum duration validator.
+
+Values must be >= m.
+*/
+func MinDuration(m time.Duration) *DurationLimitV {
+	return &DurationLimitV{m: m, def: ERROR_MIN, ok: func(v, m time.Duration) bool {
+		return v >= m
+	}}
+}
+
+/*
+Maximum duration validator.
+
+Values must be <= m.
+*/
+func MaxDuration(m time.Duration) *DurationLimitV {
+	return &DurationLimitV{m: m, def: ERROR_MAX, ok: func(v, m time.Duration) bool {
+		return v <= m
+	}}
+}
@@ -0,0 +1,35 @@
+package jsonv
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_DurationValidators(t *testing.T) {
+	cases := []struct {
+		v       DurationValidator
+		val     time.Duration
+		isValid bool
+	}{
+		{MinDuration(time.Second), time.Second, true},
+		{MinDuration(time.Second), time.Minute, true},
+		{MinDuration(time.Second), 999 * time.Millisecond, false},
+		{MinDuration(0), -time.Nanosecond, false},
+		{MaxDuration(time.Hour), time.Hour, true},
+		{MaxDuration(time.Hour), time.Second, true},
+		{MaxDuration(time.Hour), time.Hour + time.Nanosecond, false},
+	}
+
+	for i, c := range cases {
+		err := c.v.ValidateDuration(c.val)
+		if !c.isValid && err == nil {
+			t.Errorf("Case %d, Val %v: Got no error, wanted one", i, c.val)
+		} else if c.isValid && err != nil {
+			t.Errorf("Case %d, Val %v: Got error \"%v\", wanted nil", i, c.val, err)
+		}
+	}
+
+	if err := MaxDuration(time.Hour).ValidateDuration(2 * time.Hour); err == nil || err.Error() != "Must be less than or equal to 1h0m0s" {
+		t.Errorf("Got %v, want the limit formatted as a duration", err)
+	}
+}
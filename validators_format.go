@@ -0,0 +1,141 @@
+package jsonv
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+/*
+Validates that strings are in a common format, e.g. Email or UUID. As with the
+common number ranges, e.g. Port, there are no options, so each format is a
+shared instance.
+*/
+type FormatV struct {
+	ok  func(s string) bool
+	msg string
+}
+
+func (f *FormatV) ValidateString(s string) error {
+	if f.ok(s) {
+		return nil
+	}
+	return fmt.Errorf("%v", f.msg)
+}
+
+func (f *FormatV) ValidateBytes(b []byte) error {
+	return f.ValidateString(string(b))
+}
+
+var (
+	emailV    = &FormatV{isEmail, ERROR_EMAIL}
+	urlV      = &FormatV{isURL, ERROR_URL}
+	uuidV     = &FormatV{isUUID, ERROR_UUID}
+	ipv4V     = &FormatV{isIPv4, ERROR_IPV4}
+	ipv6V     = &FormatV{isIPv6, ERROR_IPV6}
+	hostnameV = &FormatV{isHostname, ERROR_HOSTNAME}
+)
+
+/*
+An email address, e.g. "bob@example.com", as per RFC 5322 but without a display
+name or angle brackets.
+*/
+func Email() *FormatV {
+	return emailV
+}
+
+/*
+An absolute URL, i.e. with a scheme and host, e.g. "https://example.com/path".
+*/
+func URL() *FormatV {
+	return urlV
+}
+
+/*
+A UUID in its canonical form, e.g. "123e4567-e89b-12d3-a456-426614174000", in
+either case.
+*/
+func UUID() *FormatV {
+	return uuidV
+}
+
+/*
+An IPv4 address in dotted decimal form, e.g. "192.168.0.1".
+*/
+func IPv4() *FormatV {
+	return ipv4V
+}
+
+/*
+An IPv6 address, e.g. "2001:db8::1".
+*/
+func IPv6() *FormatV {
+	return ipv6V
+}
+
+/*
+A hostname as per RFC 1123, e.g. "api.example.com", which needn't be fully
+qualified.
+*/
+func Hostname() *FormatV {
+	return hostnameV
+}
+
+func isEmail(s string) bool {
+	a, err := mail.ParseAddress(s)
+	return err == nil && a.Name == "" && a.Address == s
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isIPv4(s string) bool {
+	return !strings.Contains(s, ":") && net.ParseIP(s) != nil
+}
+
+func isIPv6(s string) bool {
+	return strings.Contains(s, ":") && net.ParseIP(s) != nil
+}
+
+func isHostname(s string) bool {
+	if len(s) == 0 || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
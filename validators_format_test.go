@@ -0,0 +1,80 @@
+package jsonv
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_FormatValidators(t *testing.T) {
+	cases := []struct {
+		v       *FormatV
+		val     string
+		isValid bool
+	}{
+		{Email(), "bob@example.com", true},
+		{Email(), "bob.smith+tag@mail.example.com", true},
+		{Email(), "bob", false},
+		{Email(), "bob@", false},
+		{Email(), "Bob <bob@example.com>", false},
+		{Email(), " bob@example.com", false},
+
+		{URL(), "https://example.com", true},
+		{URL(), "http://example.com:8080/path?q=1#frag", true},
+		{URL(), "/path/only", false},
+		{URL(), "example.com", false},
+		{URL(), "https://", false},
+		{URL(), "http://exa mple.com", false},
+
+		{UUID(), "123e4567-e89b-12d3-a456-426614174000", true},
+		{UUID(), "123E4567-E89B-12D3-A456-426614174000", true},
+		{UUID(), "123e4567e89b12d3a456426614174000", false},
+		{UUID(), "123e4567-e89b-12d3-a456-42661417400g", false},
+		{UUID(), "{123e4567-e89b-12d3-a456-426614174000}", false},
+
+		{IPv4(), "192.168.0.1", true},
+		{IPv4(), "256.0.0.1", false},
+		{IPv4(), "192.168.0", false},
+		{IPv4(), "::ffff:192.168.0.1", false},
+		{IPv4(), "2001:db8::1", false},
+
+		{IPv6(), "2001:db8::1", true},
+		{IPv6(), "::1", true},
+		{IPv6(), "::ffff:192.168.0.1", true},
+		{IPv6(), "192.168.0.1", false},
+		{IPv6(), "2001:db8:::1", false},
+
+		{Hostname(), "example.com", true},
+		{Hostname(), "localhost", true},
+		{Hostname(), "a-1.b-2.example", true},
+		{Hostname(), "-bad.example.com", false},
+		{Hostname(), "bad-.example.com", false},
+		{Hostname(), "bad..example.com", false},
+		{Hostname(), "under_score.com", false},
+		{Hostname(), strings.Repeat("a", 64) + ".com", false},
+		{Hostname(), "", false},
+	}
+
+	for i, c := range cases {
+		err := c.v.ValidateString(c.val)
+		if !c.isValid && err == nil {
+			t.Errorf("Case %d, Val %q: Got no error, wanted one", i, c.val)
+		} else if c.isValid && err != nil {
+			t.Errorf("Case %d, Val %q: Got error \"%v\", wanted nil", i, c.val, err)
+		}
+	}
+
+	// and in a schema
+	type server struct {
+		Host  string
+		Admin string
+	}
+	schema := Struct(Prop("Host", String(Hostname())), Prop("Admin", String(Email())))
+	err := tryParse(schema, `{"Host": "bad host", "Admin": "nobody"}`, new(server), server{})
+	if verr, ok := err.(ValidationError); !ok || len(verr) != 2 {
+		t.Fatalf("Got %v, want 2 errors", err)
+	} else if verr[0].Path != "/Host" || verr[0].Error != ERROR_HOSTNAME {
+		t.Errorf("Got %v, want %q at /Host", verr[0], ERROR_HOSTNAME)
+	} else if verr[1].Path != "/Admin" || verr[1].Error != ERROR_EMAIL {
+		t.Errorf("Got %v, want %q at /Admin", verr[1], ERROR_EMAIL)
+	}
+}
@@ -9,6 +9,10 @@ const ()
 
 /*
 Used to identify validators that can work on Integer values.
+
+Validators are only read once built, so, as with the built-in ones, a single
+instance can be shared by any number of schemas and used by concurrent parses.
+Options like WithMessage must be set before it's used.
 */
 type IntegerValidator interface {
 	ValidateInteger(i int64) error
@@ -30,19 +34,69 @@ func (f FloatValidatorFunc) ValidateFloat(i float64) error {
 	return f(i)
 }
 
+/*
+Validates integers against a limit, m, e.g. MinI. The default message can be
+replaced with WithMessage.
+*/
+type IntLimitV struct {
+	m   int64
+	ok  func(v, m int64) bool
+	def string
+	msg string
+}
+
+/*
+Replaces the default error message, e.g. "Age must be at least {limit}". Any
+"{limit}" is replaced by the limit.
+*/
+func (l *IntLimitV) WithMessage(msg string) *IntLimitV {
+	l.msg = msg
+	return l
+}
+
+func (l *IntLimitV) ValidateInteger(i int64) error {
+	if l.ok(i, l.m) {
+		return nil
+	}
+	return limitError(l.msg, l.def, l.m)
+}
+
+/*
+Validates floats against a limit, m, e.g. MinF. The default message can be
+replaced with WithMessage.
+*/
+type FloatLimitV struct {
+	m   float64
+	ok  func(v, m float64) bool
+	def string
+	msg string
+}
+
+/*
+Replaces the default error message, e.g. "Discount must be under {limit}%". Any
+"{limit}" is replaced by the limit.
+*/
+func (l *FloatLimitV) WithMessage(msg string) *FloatLimitV {
+	l.msg = msg
+	return l
+}
+
+func (l *FloatLimitV) ValidateFloat(f float64) error {
+	if l.ok(f, l.m) {
+		return nil
+	}
+	return limitError(l.msg, l.def, l.m)
+}
+
 /*
 Minimum int value validator.
 
 Values must be >= m.
 */
-func MinI(m int64) IntegerValidator {
-	return IntegerValidatorFunc(func(i int64) error {
-		if i >= m {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MIN, m)
-		}
-	})
+func MinI(m int64) *IntLimitV {
+	return &IntLimitV{m: m, def: ERROR_MIN, ok: func(v, m int64) bool {
+		return v >= m
+	}}
 }
 
 /*
@@ -50,14 +104,10 @@ Exclusive int minimum value validator.
 
 Values must be > m.
 */
-func MinEI(m int64) IntegerValidator {
-	return IntegerValidatorFunc(func(i int64) error {
-		if i > m {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MIN_EX, m)
-		}
-	})
+func MinEI(m int64) *IntLimitV {
+	return &IntLimitV{m: m, def: ERROR_MIN_EX, ok: func(v, m int64) bool {
+		return v > m
+	}}
 }
 
 /*
@@ -65,14 +115,10 @@ Maximum int value validator.
 
 Values must be <= m.
 */
-func MaxI(m int64) IntegerValidator {
-	return IntegerValidatorFunc(func(i int64) error {
-		if i <= m {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MAX, m)
-		}
-	})
+func MaxI(m int64) *IntLimitV {
+	return &IntLimitV{m: m, def: ERROR_MAX, ok: func(v, m int64) bool {
+		return v <= m
+	}}
 }
 
 /*
@@ -80,30 +126,22 @@ Exclusive int maximum value validator.
 
 Values must be < m.
 */
-func MaxEI(m int64) IntegerValidator {
-	return IntegerValidatorFunc(func(i int64) error {
-		if i < m {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MAX_EX, m)
-		}
-	})
+func MaxEI(m int64) *IntLimitV {
+	return &IntLimitV{m: m, def: ERROR_MAX_EX, ok: func(v, m int64) bool {
+		return v < m
+	}}
 }
 
 /*
 Validates that the integer value is a multiple of another integer.
 */
-func MulOfI(m int64) IntegerValidator {
+func MulOfI(m int64) *IntLimitV {
 	if m <= 0 {
 		panic(fmt.Errorf("Multiple must be >= 0, %v is not valid", m))
 	}
-	return IntegerValidatorFunc(func(i int64) error {
-		if i%m == 0 {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MULOF, m)
-		}
-	})
+	return &IntLimitV{m: m, def: ERROR_MULOF, ok: func(v, m int64) bool {
+		return v%m == 0
+	}}
 }
 
 /*
@@ -111,14 +149,10 @@ Minimum float value validator.
 
 Values must be >= m.
 */
-func MinF(m float64) FloatValidator {
-	return FloatValidatorFunc(func(i float64) error {
-		if i >= m {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MIN, m)
-		}
-	})
+func MinF(m float64) *FloatLimitV {
+	return &FloatLimitV{m: m, def: ERROR_MIN, ok: func(v, m float64) bool {
+		return v >= m
+	}}
 }
 
 /*
@@ -126,14 +160,10 @@ Exclusive float minimum value validator.
 
 Values must be > m.
 */
-func MinEF(m float64) FloatValidator {
-	return FloatValidatorFunc(func(f float64) error {
-		if f > m {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MIN_EX, m)
-		}
-	})
+func MinEF(m float64) *FloatLimitV {
+	return &FloatLimitV{m: m, def: ERROR_MIN_EX, ok: func(v, m float64) bool {
+		return v > m
+	}}
 }
 
 /*
@@ -141,14 +171,10 @@ Maximum float value validator.
 
 Values must be <= m.
 */
-func MaxF(m float64) FloatValidator {
-	return FloatValidatorFunc(func(f float64) error {
-		if f <= m {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MAX, m)
-		}
-	})
+func MaxF(m float64) *FloatLimitV {
+	return &FloatLimitV{m: m, def: ERROR_MAX, ok: func(v, m float64) bool {
+		return v <= m
+	}}
 }
 
 /*
@@ -156,28 +182,127 @@ Exclusive float maximum value validator.
 
 Values must be < m.
 */
-func MaxEF(m float64) FloatValidator {
-	return FloatValidatorFunc(func(i float64) error {
-		if i < m {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MAX_EX, m)
-		}
-	})
+func MaxEF(m float64) *FloatLimitV {
+	return &FloatLimitV{m: m, def: ERROR_MAX_EX, ok: func(v, m float64) bool {
+		return v < m
+	}}
 }
 
 /*
 Validates that the integer value is a multiple of another integer.
 */
-func MulOfF(m float64) FloatValidator {
+func MulOfF(m float64) *FloatLimitV {
 	if m <= 0 || math.IsInf(m, 0) || math.IsNaN(m) {
 		panic(fmt.Errorf("Multiple must be >= 0, %v is not valid", m))
 	}
-	return FloatValidatorFunc(func(f float64) error {
-		if math.Mod(f, m) == 0 {
-			return nil
-		} else {
-			return fmt.Errorf(ERROR_MULOF, m)
-		}
-	})
+	return &FloatLimitV{m: m, def: ERROR_MULOF, ok: func(v, m float64) bool {
+		return math.Mod(v, m) == 0
+	}}
+}
+
+/*
+Validates that integers are between min and max, inclusive. Unlike MinI and
+MaxI, there are no options, so the common ranges, e.g. Port, are shared
+instances.
+*/
+type IntRangeV struct {
+	min, max int64
+	msg      string
+}
+
+/*
+Values must be >= min and <= max.
+*/
+func RangeI(min, max int64) *IntRangeV {
+	return &IntRangeV{min, max, ERROR_RANGE}
+}
+
+func (r *IntRangeV) ValidateInteger(i int64) error {
+	if i >= r.min && i <= r.max {
+		return nil
+	}
+	return fmt.Errorf(r.msg, r.min, r.max)
+}
+
+/*
+Validates that floats are between min and max, inclusive. NaN is never valid.
+
+These can also check integers, e.g. Integer(Percent()).
+*/
+type FloatRangeV struct {
+	min, max float64
+	msg      string
+}
+
+/*
+Values must be >= min and <= max.
+*/
+func RangeF(min, max float64) *FloatRangeV {
+	return &FloatRangeV{min, max, ERROR_RANGE}
+}
+
+func (r *FloatRangeV) ValidateFloat(f float64) error {
+	// written so NaN fails
+	if f >= r.min && f <= r.max {
+		return nil
+	}
+	return fmt.Errorf(r.msg, r.min, r.max)
+}
+
+func (r *FloatRangeV) ValidateInteger(i int64) error {
+	return r.ValidateFloat(float64(i))
+}
+
+var (
+	portV      = &IntRangeV{1, 65535, ERROR_PORT}
+	percentV   = &FloatRangeV{0, 100, ERROR_PERCENT}
+	latitudeV  = &FloatRangeV{-90, 90, ERROR_LATITUDE}
+	longitudeV = &FloatRangeV{-180, 180, ERROR_LONGITUDE}
+)
+
+/*
+A TCP/UDP port number, 1 to 65535.
+*/
+func Port() *IntRangeV {
+	return portV
+}
+
+/*
+A percentage, 0 to 100.
+*/
+func Percent() *FloatRangeV {
+	return percentV
+}
+
+/*
+A latitude in degrees, -90 to 90.
+*/
+func Latitude() *FloatRangeV {
+	return latitudeV
+}
+
+/*
+A longitude in degrees, -180 to 180.
+*/
+func Longitude() *FloatRangeV {
+	return longitudeV
+}
+
+/*
+As AllowlistS, for integers, e.g. the ids of rows that currently exist.
+*/
+type AllowlistIV struct {
+	fn  func(i int64) bool
+	msg string
+}
+
+func AllowlistI(fn func(i int64) bool, message string) *AllowlistIV {
+	return &AllowlistIV{fn: fn, msg: message}
+}
+
+func (a *AllowlistIV) ValidateInteger(i int64) error {
+	if a.fn(i) {
+		return nil
+	}
+	return fmt.Errorf("%v", a.msg)
 }
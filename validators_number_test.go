@@ -1,6 +1,8 @@
 package jsonv
 
 import (
+	"math"
+	"sync"
 	"testing"
 )
 
@@ -121,3 +123,126 @@ func Test_FloatValidators(t *testing.T) {
 		}
 	}
 }
+
+func Test_NumberValidatorMessages(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{MinI(18).ValidateInteger(17), "Must be greater than or equal to 18"},
+		{MinI(18).WithMessage("Must be {limit} or older").ValidateInteger(17), "Must be 18 or older"},
+		{MaxEI(5).WithMessage("Too many").ValidateInteger(5), "Too many"},
+		{MaxF(1.5).ValidateFloat(2), "Must be less than or equal to 1.5"},
+		{MaxF(1.5).WithMessage("At most {limit}, please").ValidateFloat(2), "At most 1.5, please"},
+		{RangeI(1, 5).ValidateInteger(6), "Must be between 1 and 5"},
+		{RangeF(0.5, 1).ValidateFloat(0), "Must be between 0.5 and 1"},
+		{Port().ValidateInteger(0), "Must be a port number, between 1 and 65535"},
+		{Percent().ValidateFloat(101), "Must be a percentage, between 0 and 100"},
+		{Latitude().ValidateFloat(-91), "Must be a latitude, between -90 and 90 degrees"},
+		{Longitude().ValidateFloat(181), "Must be a longitude, between -180 and 180 degrees"},
+	}
+
+	for i, c := range cases {
+		if c.err == nil || c.err.Error() != c.want {
+			t.Errorf("Case %d: Got \"%v\", want \"%v\"", i, c.err, c.want)
+		}
+	}
+}
+
+func Test_RangeValidators(t *testing.T) {
+	ints := []struct {
+		v       IntegerValidator
+		val     int64
+		isValid bool
+	}{
+		{RangeI(-5, 5), -6, false},
+		{RangeI(-5, 5), -5, true},
+		{RangeI(-5, 5), 5, true},
+		{RangeI(-5, 5), 6, false},
+		{Port(), 0, false},
+		{Port(), 1, true},
+		{Port(), 65535, true},
+		{Port(), 65536, false},
+		{Port(), -1, false},
+		{Percent(), -1, false},
+		{Percent(), 0, true},
+		{Percent(), 100, true},
+		{Percent(), 101, false},
+	}
+
+	for i, c := range ints {
+		err := c.v.ValidateInteger(c.val)
+		if !c.isValid && err == nil {
+			t.Errorf("Int case %d, Val %v: Got no error, wanted one", i, c.val)
+		} else if c.isValid && err != nil {
+			t.Errorf("Int case %d, Val %v: Got error \"%v\", wanted nil", i, c.val, err)
+		}
+	}
+
+	floats := []struct {
+		v       FloatValidator
+		val     float64
+		isValid bool
+	}{
+		{RangeF(-0.5, 0.5), -0.5, true},
+		{RangeF(-0.5, 0.5), 0.5, true},
+		{RangeF(-0.5, 0.5), 0.50001, false},
+		{Percent(), -0.001, false},
+		{Percent(), 0, true},
+		{Percent(), 99.999, true},
+		{Percent(), 100, true},
+		{Percent(), 100.001, false},
+		{Latitude(), -90.0001, false},
+		{Latitude(), -90, true},
+		{Latitude(), 90, true},
+		{Latitude(), 90.0001, false},
+		{Longitude(), -180.0001, false},
+		{Longitude(), -180, true},
+		{Longitude(), 180, true},
+		{Longitude(), 180.0001, false},
+		{Longitude(), math.NaN(), false},
+		{Longitude(), math.Inf(1), false},
+	}
+
+	for i, c := range floats {
+		err := c.v.ValidateFloat(c.val)
+		if !c.isValid && err == nil {
+			t.Errorf("Float case %d, Val %v: Got no error, wanted one", i, c.val)
+		} else if c.isValid && err != nil {
+			t.Errorf("Float case %d, Val %v: Got error \"%v\", wanted nil", i, c.val, err)
+		}
+	}
+}
+
+func Test_SharedValidators(t *testing.T) {
+	type place struct {
+		Lat, Lon float64
+		Port     int64
+	}
+
+	// one instance of each, used by many schemas at once
+	min := MinF(-180)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			schema := Struct(
+				Prop("Lat", Float(min, Latitude())),
+				Prop("Lon", Float(min, Longitude())),
+				Prop("Port", Integer(Port())),
+			)
+			parser := Parser(&place{}, schema)
+			for j := 0; j < 100; j++ {
+				if err := parser.ValidateBytes([]byte(`{"Lat": -33.9, "Lon": 151.2, "Port": 443}`)); err != nil {
+					t.Error(err)
+				} else if err := parser.ValidateBytes([]byte(`{"Lat": 91, "Lon": -181, "Port": 0}`)); err == nil {
+					t.Error("Got no error, wanted one")
+				} else if len(err.(ValidationError)) != 4 {
+					t.Errorf("Got %v, want 4 errors", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
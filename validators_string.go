@@ -3,6 +3,7 @@ package jsonv
 import (
 	"fmt"
 	"regexp"
+	"unicode/utf8"
 )
 
 type StringValidator interface {
@@ -26,82 +27,319 @@ func (f BytesValidatorFunc) ValidateBytes(b []byte) error {
 }
 
 /*
-The Min Length validator.
+The Min Length validator. Lengths are in bytes, see MinRunes to count
+characters instead.
 */
 type MinLenV struct {
-	l int
+	l   int
+	msg string
 }
 
 func MinLen(l int) *MinLenV {
 	if l < 0 {
 		panic(fmt.Errorf("Minimum allowed length must be >= 0"))
 	}
-	return &MinLenV{l}
+	return &MinLenV{l: l}
+}
+
+/*
+Replaces the default error message, e.g. "Password must be at least {limit}
+characters". Any "{limit}" is replaced by the length limit.
+*/
+func (m *MinLenV) WithMessage(msg string) *MinLenV {
+	m.msg = msg
+	return m
 }
 
 func (m *MinLenV) ValidateString(s string) error {
 	if len(s) < m.l {
-		return fmt.Errorf(ERROR_MIN_LEN_STR, m.l)
+		return limitError(m.msg, ERROR_MIN_LEN_STR, m.l)
 	}
 	return nil
 }
 
 func (m *MinLenV) ValidateBytes(b []byte) error {
 	if len(b) < m.l {
-		return fmt.Errorf(ERROR_MIN_LEN_STR, m.l)
+		return limitError(m.msg, ERROR_MIN_LEN_STR, m.l)
 	}
 	return nil
 }
 
 /*
-The Max Length validator.
+The Max Length validator. Lengths are in bytes, see MaxRunes to count
+characters instead.
 */
 type MaxLenV struct {
-	l int
+	l   int
+	msg string
 }
 
 func MaxLen(l int) *MaxLenV {
 	if l < 0 {
 		panic(fmt.Errorf("Maximum allowed length must be >= 0"))
 	}
-	return &MaxLenV{l}
+	return &MaxLenV{l: l}
+}
+
+/*
+Replaces the default error message, e.g. "Nickname must be no more than
+{limit} characters". Any "{limit}" is replaced by the length limit.
+*/
+func (m *MaxLenV) WithMessage(msg string) *MaxLenV {
+	m.msg = msg
+	return m
 }
 
 func (m *MaxLenV) ValidateString(s string) error {
 	if len(s) > m.l {
-		return fmt.Errorf(ERROR_MAX_LEN_STR, m.l)
+		return limitError(m.msg, ERROR_MAX_LEN_STR, m.l)
 	}
 	return nil
 }
 
 func (m *MaxLenV) ValidateBytes(b []byte) error {
 	if len(b) > m.l {
-		return fmt.Errorf(ERROR_MAX_LEN_STR, m.l)
+		return limitError(m.msg, ERROR_MAX_LEN_STR, m.l)
 	}
 	return nil
 }
 
-type PatternV struct {
-	r   *regexp.Regexp
+/*
+As MinLen, but counts characters, i.e. Unicode code points, rather than bytes,
+e.g. for user-facing text like display names. Invalid UTF-8 counts one
+character per bad byte.
+*/
+type MinRunesV struct {
+	l   int
+	msg string
+}
+
+func MinRunes(l int) *MinRunesV {
+	if l < 0 {
+		panic(fmt.Errorf("Minimum allowed length must be >= 0"))
+	}
+	return &MinRunesV{l: l}
+}
+
+/*
+Replaces the default error message, e.g. "Name must be at least {limit}
+letters". Any "{limit}" is replaced by the length limit.
+*/
+func (m *MinRunesV) WithMessage(msg string) *MinRunesV {
+	m.msg = msg
+	return m
+}
+
+func (m *MinRunesV) ValidateString(s string) error {
+	// every character is at least one byte, so too few bytes is too few characters
+	if len(s) < m.l || utf8.RuneCountInString(s) < m.l {
+		return limitError(m.msg, ERROR_MIN_RUNES, m.l)
+	}
+	return nil
+}
+
+func (m *MinRunesV) ValidateBytes(b []byte) error {
+	if len(b) < m.l || utf8.RuneCount(b) < m.l {
+		return limitError(m.msg, ERROR_MIN_RUNES, m.l)
+	}
+	return nil
+}
+
+/*
+As MaxLen, but counts characters, i.e. Unicode code points, rather than bytes,
+so "héllo wörld" is within MaxRunes(11). Invalid UTF-8 counts one character per
+bad byte.
+*/
+type MaxRunesV struct {
+	l   int
 	msg string
 }
 
+func MaxRunes(l int) *MaxRunesV {
+	if l < 0 {
+		panic(fmt.Errorf("Maximum allowed length must be >= 0"))
+	}
+	return &MaxRunesV{l: l}
+}
+
+/*
+Replaces the default error message, e.g. "Name must be no more than {limit}
+letters". Any "{limit}" is replaced by the length limit.
+*/
+func (m *MaxRunesV) WithMessage(msg string) *MaxRunesV {
+	m.msg = msg
+	return m
+}
+
+func (m *MaxRunesV) ValidateString(s string) error {
+	// and only when there are too many bytes might there be too many characters
+	if len(s) > m.l && utf8.RuneCountInString(s) > m.l {
+		return limitError(m.msg, ERROR_MAX_RUNES, m.l)
+	}
+	return nil
+}
+
+func (m *MaxRunesV) ValidateBytes(b []byte) error {
+	if len(b) > m.l && utf8.RuneCount(b) > m.l {
+		return limitError(m.msg, ERROR_MAX_RUNES, m.l)
+	}
+	return nil
+}
+
+type PatternV struct {
+	r      *regexp.Regexp
+	msg    string
+	maxLen int
+}
+
 /*
 Builds a regex pattern based string validator.
 
 re: The regex string used for validation.
 message: A human friendly message to use in the ValidationError
 
-Note: Will panic if re fails to compile.
+Go's regexp package is RE2 based, so matching takes time linear in the length
+of the input and can't be made to backtrack catastrophically. That's still
+proportional to whatever a client sends, though, so see MaxInputLen.
+
+Note: Will panic if re fails to compile, see PatternError.
 */
 func Pattern(re, message string) *PatternV {
-	return &PatternV{regexp.MustCompile(re), message}
+	if p, err := PatternError(re, message); err != nil {
+		panic(err)
+	} else {
+		return p
+	}
+}
+
+/*
+Same as Pattern, but returns an error instead of panicing, e.g. for patterns
+loaded from config.
+*/
+func PatternError(re, message string) (*PatternV, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return nil, err
+	}
+	return &PatternV{r: r, msg: message}, nil
+}
+
+/*
+Rejects strings longer than n bytes without running the regex on them at all.
+The default, 0, is no limit.
+*/
+func (p *PatternV) MaxInputLen(n int) *PatternV {
+	if n < 0 {
+		panic(fmt.Errorf("Maximum input length must be >= 0"))
+	}
+	p.maxLen = n
+	return p
 }
 
 func (p *PatternV) ValidateString(s string) error {
-	if p.r.MatchString(s) {
+	if p.maxLen > 0 && len(s) > p.maxLen {
+		return fmt.Errorf(ERROR_MAX_LEN_STR, p.maxLen)
+	} else if p.r.MatchString(s) {
 		return nil
 	} else {
 		return fmt.Errorf("%v", p.msg)
 	}
 }
+
+/*
+Validates that a string is itself a single, well-formed JSON value, e.g. for
+fields that carry embedded JSON.
+*/
+type ValidJSONV struct {
+}
+
+func ValidJSON() *ValidJSONV {
+	return &ValidJSONV{}
+}
+
+func (v *ValidJSONV) ValidateString(s string) error {
+	return v.ValidateBytes([]byte(s))
+}
+
+func (v *ValidJSONV) ValidateBytes(b []byte) error {
+	if err := validJSON(b); err != nil {
+		return fmt.Errorf(ERROR_INVALID_JSON, err)
+	}
+	return nil
+}
+
+/*
+Only allows ASCII, i.e. no bytes outside 0x00-0x7F, for downstreams that can't
+handle anything else. The error gives the position of the first byte that
+isn't, counting from 0.
+*/
+type ASCIIOnlyV struct {
+}
+
+func ASCIIOnly() *ASCIIOnlyV {
+	return &ASCIIOnlyV{}
+}
+
+func (v *ASCIIOnlyV) ValidateString(s string) error {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return fmt.Errorf(ERROR_NOT_ASCII, i)
+		}
+	}
+	return nil
+}
+
+func (v *ASCIIOnlyV) ValidateBytes(b []byte) error {
+	for i, c := range b {
+		if c >= utf8.RuneSelf {
+			return fmt.Errorf(ERROR_NOT_ASCII, i)
+		}
+	}
+	return nil
+}
+
+/*
+Only allows strings for which fn returns true, so the allowed set can come from
+a live source, e.g. a database table of country codes, rather than being fixed
+when the schema is built as with Enum.
+
+message: A human friendly message to use in the ValidationError
+
+fn is called for every value validated, possibly concurrently, so it must be
+safe for concurrent use and should be quick, e.g. a lookup in a cached set.
+*/
+type AllowlistSV struct {
+	fn  func(s string) bool
+	msg string
+}
+
+func AllowlistS(fn func(s string) bool, message string) *AllowlistSV {
+	return &AllowlistSV{fn: fn, msg: message}
+}
+
+func (a *AllowlistSV) ValidateString(s string) error {
+	if a.fn(s) {
+		return nil
+	}
+	return fmt.Errorf("%v", a.msg)
+}
+
+/*
+As AllowlistS, for the []byte values of the bytes parsers, without converting
+them to strings. fn must not keep b.
+*/
+type AllowlistBV struct {
+	fn  func(b []byte) bool
+	msg string
+}
+
+func AllowlistB(fn func(b []byte) bool, message string) *AllowlistBV {
+	return &AllowlistBV{fn: fn, msg: message}
+}
+
+func (a *AllowlistBV) ValidateBytes(b []byte) error {
+	if a.fn(b) {
+		return nil
+	}
+	return fmt.Errorf("%v", a.msg)
+}
@@ -1,6 +1,8 @@
 package jsonv
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -20,6 +22,19 @@ func Test_StringValidators(t *testing.T) {
 		{MaxLen(0), "z", false},
 		{MaxLen(1), "", true},
 		{MaxLen(1), "sasas", false},
+		{MaxLen(11), "héllo wörld", false},
+
+		{MinRunes(0), "", true},
+		{MinRunes(1), "", false},
+		{MinRunes(2), "é", false},
+		{MinRunes(2), "hé", true},
+		{MaxRunes(0), "", true},
+		{MaxRunes(0), "z", false},
+		{MaxRunes(11), "héllo wörld", true},
+		{MaxRunes(10), "héllo wörld", false},
+		{MaxRunes(1), "😀", true},
+		{MaxRunes(1), "😀😀", false},
+		{MaxRunes(2), "\xff\xfe\xfd", false},
 
 		{Pattern("[a-z]+", "Must be at least one lowercase letter"), "sasas", true},
 		{Pattern("[a-z]+", ""), "SASASA", false},
@@ -30,6 +45,20 @@ func Test_StringValidators(t *testing.T) {
 		{Pattern("[a-z]+$", ""), "   sasas     ", false},
 		{Pattern("Z[a-z]+", ""), "Zsasas", true},
 		{Pattern("Z[a-z]+", ""), "sasas", false},
+
+		{ValidJSON(), `{"a": [1, 2, {"b": null}]}`, true},
+		{ValidJSON(), ` "just a string" `, true},
+		{ValidJSON(), `12.5e3`, true},
+		{ValidJSON(), ``, false},
+		{ValidJSON(), `{"a": [1, 2}`, false},
+		{ValidJSON(), `{"a": 1} {}`, false},
+		{ValidJSON(), `{'a': 1}`, false},
+
+		{ASCIIOnly(), "", true},
+		{ASCIIOnly(), "Plain old text, with\ttabs & \x00 control chars~", true},
+		{ASCIIOnly(), "café", false},
+		{ASCIIOnly(), "\x80", false},
+		{ASCIIOnly(), "emoji 😀", false},
 	}
 
 	for i, c := range cases {
@@ -41,3 +70,132 @@ func Test_StringValidators(t *testing.T) {
 		}
 	}
 }
+
+func Test_ValidJSONMessage(t *testing.T) {
+	err := ValidJSON().ValidateBytes([]byte(`{"a": [1, 2}`))
+	if want := "Must be valid JSON: Expected ',' or ']', not }, at byte 12"; err == nil || err.Error() != want {
+		t.Errorf("Got \"%v\", want \"%v\"", err, want)
+	}
+
+	// trailing data is reported from its first non-space byte
+	err = ValidJSON().ValidateString("{\"a\": 1} \n\t x")
+	if want := "Must be valid JSON: Unexpected data after value, at byte 12"; err == nil || err.Error() != want {
+		t.Errorf("Got \"%v\", want \"%v\"", err, want)
+	}
+}
+
+func Test_LengthValidatorMessages(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{MinLen(8).ValidateString("secret"), "Must be at least 8 bytes long"},
+		{MinRunes(8).ValidateString("secret"), "Must be at least 8 characters long"},
+		{MaxRunes(3).ValidateString("héllo"), "Must be no more than 3 characters long"},
+		{MinRunes(3).WithMessage("Name must be at least {limit} letters").ValidateBytes([]byte("é")), "Name must be at least 3 letters"},
+		{MinLen(8).WithMessage("Password must be at least {limit} characters").ValidateString("secret"), "Password must be at least 8 characters"},
+		{MaxLen(2).ValidateBytes([]byte("abc")), "Must be no more than 2 bytes long"},
+		{MaxRunes(2).ValidateBytes([]byte("abc")), "Must be no more than 2 characters long"},
+		{MaxLen(2).WithMessage("Too long").ValidateBytes([]byte("abc")), "Too long"},
+		{MinItems(1).ValidateSlice(reflect.ValueOf([]int{})), "Please provide at least 1 items"},
+		{MinItems(1).WithMessage("Pick at least {limit} tag").ValidateSlice(reflect.ValueOf([]int{})), "Pick at least 1 tag"},
+		{MaxItems(1).WithMessage("Pick one").ValidateSlice(reflect.ValueOf([]int{1, 2})), "Pick one"},
+		{MaxLen(2).WithMessage("Must be under 100%").ValidateString("abc"), "Must be under 100%"},
+		{MaxLen(2).WithMessage("Must be under {limit} (100%)").ValidateString("abc"), "Must be under 2 (100%)"},
+	}
+
+	for i, c := range cases {
+		if c.err == nil || c.err.Error() != c.want {
+			t.Errorf("Case %d: Got \"%v\", want \"%v\"", i, c.err, c.want)
+		}
+	}
+}
+
+func Test_ASCIIOnlyMessage(t *testing.T) {
+	if err := ASCIIOnly().ValidateString("café au lait"); err == nil || err.Error() != "Must only contain ASCII characters, found another at byte 3" {
+		t.Errorf("Got \"%v\", want the position of the é", err)
+	}
+	if err := ASCIIOnly().ValidateBytes([]byte("ab\xffcd")); err == nil || err.Error() != "Must only contain ASCII characters, found another at byte 2" {
+		t.Errorf("Got \"%v\", want the position of the 0xff", err)
+	}
+	if err := ASCIIOnly().ValidateBytes([]byte("abc")); err != nil {
+		t.Errorf("Got \"%v\", want nil", err)
+	}
+}
+
+func Test_PatternMaxInputLen(t *testing.T) {
+	v := Pattern("^[a-z]+$", "Must be lowercase letters").MaxInputLen(10)
+
+	if err := v.ValidateString("abcdefghij"); err != nil {
+		t.Errorf("Got error \"%v\" at the limit, wanted nil", err)
+	}
+	if err := v.ValidateString("ABC"); err == nil || err.Error() != "Must be lowercase letters" {
+		t.Errorf("Got \"%v\", want the pattern's message", err)
+	}
+
+	long := strings.Repeat("a", 1<<20)
+	if err := v.ValidateString(long); err == nil || err.Error() != "Must be no more than 10 bytes long" {
+		t.Errorf("Got \"%v\" for a long input, want a length error", err)
+	}
+	if err := Pattern("^[a-z]+$", "").ValidateString(long); err != nil {
+		t.Errorf("Got error \"%v\" with no limit, wanted nil", err)
+	}
+}
+
+func Test_PatternError(t *testing.T) {
+	if v, err := PatternError("^[a-z]+$", "Must be lowercase letters"); err != nil {
+		t.Fatalf("Got error %v, want nil", err)
+	} else if err := v.ValidateString("ABC"); err == nil || err.Error() != "Must be lowercase letters" {
+		t.Errorf("Got \"%v\", want the pattern's message", err)
+	}
+
+	if v, err := PatternError("[a-z", ""); err == nil {
+		t.Errorf("Got %v, want an error", v)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Got no panic from Pattern, wanted one")
+		}
+	}()
+	Pattern("[a-z", "")
+}
+
+func Test_Allowlist(t *testing.T) {
+	// stands in for a table that changes while the schema is in use
+	countries := map[string]bool{"AU": true, "NZ": true}
+	ids := map[int64]bool{1: true, 2: true}
+
+	type order struct {
+		Country string
+		Ids     []int64
+		Code    []byte
+	}
+	schema := Struct(
+		Prop("Country", String(AllowlistS(func(s string) bool { return countries[s] }, "Unknown country"))),
+		Prop("Ids", Slice(Integer(AllowlistI(func(i int64) bool { return ids[i] }, "Unknown id")))),
+		Prop("Code", Bytes(AllowlistB(func(b []byte) bool { return countries[string(b)] }, "Unknown code"))),
+	)
+
+	json := `{"Country": "NZ", "Ids": [1, 2], "Code": "AU"}`
+	if err := tryParse(schema, json, &order{}, order{"NZ", []int64{1, 2}, []byte("AU")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// changes to the allowed set are seen straight away
+	delete(countries, "NZ")
+	countries["US"] = true
+	delete(ids, 2)
+
+	var got order
+	err := Parser(&got, schema).Parse(strings.NewReader(`{"Country": "NZ", "Ids": [1, 2], "Code": "US"}`), &got)
+	verr, ok := err.(ValidationError)
+	if !ok || len(verr) != 2 || verr[0] != (InvalidData{Path: "/Country", Error: "Unknown country"}) || verr[1].Error != "Unknown id" {
+		t.Fatalf("Got %v, want errors for Country and the second id", err)
+	}
+
+	err = Parser(&got, schema).Parse(strings.NewReader(`{"Country": "US", "Ids": [1], "Code": "NZ"}`), &got)
+	if want := (ValidationError{{Path: "/Code", Error: "Unknown code"}}); !reflect.DeepEqual(err, want) {
+		t.Fatalf("Got %v, want %v", err, want)
+	}
+}